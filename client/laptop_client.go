@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"gitlab.com/iruldev/grpc-class/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -12,6 +13,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -35,6 +37,8 @@ func (c *LaptopClient) CreateLaptop(laptop *proto.Laptop) {
 		st, ok := status.FromError(err)
 		if ok && st.Code() == codes.AlreadyExists {
 			log.Print("laptop already exists")
+		} else if ok && st.Code() == codes.InvalidArgument {
+			logFieldViolations(st)
 		} else {
 			log.Fatal("cannot create laptop: ", err)
 		}
@@ -137,13 +141,32 @@ func (c *LaptopClient) UploadImage(laptopID, imagePath string) {
 	log.Printf("image uploaded with id: %s, size: %d", res.GetId(), res.GetSize())
 }
 
-func (c *LaptopClient) RateLaptop(laptopIDs []string, scores []float64) error {
+// RatingResult is the outcome of one rating sent by RateLaptop.
+type RatingResult struct {
+	LaptopID     string
+	Score        float64
+	RatedCount   uint32
+	AverageScore float64
+	// Err is the status error of a rating the server rejected, nil if the
+	// rating was added
+	Err error
+}
+
+// RateLaptop rates each laptop with the score at the same index. A rating
+// the server rejects is reported in its result, the returned error tells
+// that the stream itself failed.
+func (c *LaptopClient) RateLaptop(laptopIDs []string, scores []float64) ([]*RatingResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	stream, err := c.service.RateLaptop(ctx)
 	if err != nil {
-		return fmt.Errorf("cannot rate laptop: %v", err)
+		return nil, fmt.Errorf("cannot rate laptop: %v", err)
+	}
+
+	results := make([]*RatingResult, len(laptopIDs))
+	for i, laptopID := range laptopIDs {
+		results[i] = &RatingResult{LaptopID: laptopID, Score: scores[i]}
 	}
 
 	waitResponse := make(chan error)
@@ -161,18 +184,29 @@ func (c *LaptopClient) RateLaptop(laptopIDs []string, scores []float64) error {
 				return
 			}
 			log.Print("received response: ", res)
+
+			i, err := strconv.Atoi(res.GetRequestId())
+			if err != nil || i < 0 || i >= len(results) {
+				waitResponse <- fmt.Errorf("unexpected response request id: %q", res.GetRequestId())
+				return
+			}
+
+			results[i].RatedCount = res.GetRatedCount()
+			results[i].AverageScore = res.GetAverageScore()
+			results[i].Err = status.ErrorProto(res.GetStatus())
 		}
 	}()
 
 	for i, laptopID := range laptopIDs {
 		req := &proto.RateLaptopRequest{
-			LaptopId: laptopID,
-			Score:    scores[i],
+			LaptopId:  laptopID,
+			Score:     scores[i],
+			RequestId: strconv.Itoa(i),
 		}
 
 		err := stream.Send(req)
 		if err != nil {
-			return fmt.Errorf("cannot send stream reqeust: %v - %v", err, stream.RecvMsg(nil))
+			return nil, fmt.Errorf("cannot send stream reqeust: %v - %v", err, stream.RecvMsg(nil))
 		}
 
 		log.Print("sent request: ", req)
@@ -180,9 +214,26 @@ func (c *LaptopClient) RateLaptop(laptopIDs []string, scores []float64) error {
 
 	err = stream.CloseSend()
 	if err != nil {
-		return fmt.Errorf("cannot close send: %v", err)
+		return nil, fmt.Errorf("cannot close send: %v", err)
 	}
 
 	err = <-waitResponse
-	return err
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// logFieldViolations logs every invalid field reported in the status details.
+func logFieldViolations(st *status.Status) {
+	log.Print("invalid laptop: ", st.Message())
+	for _, detail := range st.Details() {
+		badRequest, ok := detail.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, violation := range badRequest.GetFieldViolations() {
+			log.Printf("  %s %s", violation.GetField(), violation.GetDescription())
+		}
+	}
 }
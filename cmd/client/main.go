@@ -22,10 +22,13 @@ func testSearchLaptop(laptopClient *client.LaptopClient) {
 		laptopClient.CreateLaptop(sample.NewLaptop())
 	}
 
+	maxPrice := 3000.0
+	minCores := uint32(4)
+	minGhz := 2.5
 	filter := &proto.Filter{
-		MaxPriceUsd: 3000,
-		MinCpuCores: 4,
-		MinCpuGhz:   2.5,
+		MaxPriceUsd: &maxPrice,
+		MinCpuCores: &minCores,
+		MinCpuGhz:   &minGhz,
 		MinRam: &proto.Memory{
 			Value: 8,
 			Unit:  proto.Memory_GIGABYTE,
@@ -65,10 +68,19 @@ func testRateLaptop(laptopClient *client.LaptopClient) {
 			scores[i] = sample.RandomLaptopScore()
 		}
 
-		err := laptopClient.RateLaptop(laptopIDs, scores)
+		results, err := laptopClient.RateLaptop(laptopIDs, scores)
 		if err != nil {
 			log.Fatal(err)
 		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				log.Printf("cannot rate laptop %s with %.2f: %v", result.LaptopID, result.Score, result.Err)
+				continue
+			}
+			log.Printf("rated laptop %s with %.2f: count = %d, average = %.2f",
+				result.LaptopID, result.Score, result.RatedCount, result.AverageScore)
+		}
 	}
 }
 
@@ -81,9 +93,21 @@ const (
 func authMethods() map[string]bool {
 	const laptopServicePath = "/grpc.class.LaptopService/"
 	return map[string]bool{
-		laptopServicePath + "CreateLaptop": true,
-		laptopServicePath + "UploadImage":  true,
-		laptopServicePath + "RateLaptop":   true,
+		laptopServicePath + "CreateLaptop":    true,
+		laptopServicePath + "GetLaptop":       true,
+		laptopServicePath + "UpdateLaptop":    true,
+		laptopServicePath + "DeleteLaptop":    true,
+		laptopServicePath + "UploadImage":     true,
+		laptopServicePath + "RateLaptop":      true,
+		laptopServicePath + "RetractRating":   true,
+		laptopServicePath + "GetRating":       true,
+		laptopServicePath + "GetRatings":      true,
+		laptopServicePath + "TopRatedLaptops": true,
+		laptopServicePath + "CreateReview":    true,
+		laptopServicePath + "EditReview":      true,
+		laptopServicePath + "DeleteReview":    true,
+		laptopServicePath + "ListReviews":     true,
+		laptopServicePath + "HideReview":      true,
 	}
 }
 
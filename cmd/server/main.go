@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"flag"
 	"fmt"
 	"gitlab.com/iruldev/grpc-class/engine/middleware"
@@ -11,12 +13,26 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 	"log"
+	_ "modernc.org/sqlite"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
+// seedUsers creates the default users in an empty store, so users kept in a
+// persistent store are not recreated on every start.
 func seedUsers(userRepo repository.UserRepository) error {
-	err := createUser(userRepo, "admin1", "secret", "admin")
+	count, err := userRepo.Count()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	err = createUser(userRepo, "admin1", "secret", "admin")
 	if err != nil {
 		return err
 	}
@@ -34,24 +50,178 @@ func createUser(userRepo repository.UserRepository, username, password, role str
 const (
 	secretKey     = "secret"
 	tokenDuration = 15 * time.Minute
+	imageFolder   = "img"
 )
 
 func accessibleRoles() map[string][]string {
 	const laptopServicePath = "/grpc.class.LaptopService/"
 	return map[string][]string{
-		laptopServicePath + "CreateLaptop": {"admin"},
-		laptopServicePath + "UploadImage":  {"admin"},
-		laptopServicePath + "RateLaptop":   {"admin", "user"},
+		laptopServicePath + "CreateLaptop":    {"admin"},
+		laptopServicePath + "GetLaptop":       {"admin", "user"},
+		laptopServicePath + "UpdateLaptop":    {"admin"},
+		laptopServicePath + "DeleteLaptop":    {"admin"},
+		laptopServicePath + "UploadImage":     {"admin"},
+		laptopServicePath + "RateLaptop":      {"admin", "user"},
+		laptopServicePath + "RetractRating":   {"admin", "user"},
+		laptopServicePath + "GetRating":       {"admin", "user"},
+		laptopServicePath + "GetRatings":      {"admin", "user"},
+		laptopServicePath + "TopRatedLaptops": {"admin", "user"},
+		laptopServicePath + "CreateReview":    {"admin", "user"},
+		laptopServicePath + "EditReview":      {"admin", "user"},
+		laptopServicePath + "DeleteReview":    {"admin", "user"},
+		laptopServicePath + "ListReviews":     {"admin", "user"},
+		laptopServicePath + "HideReview":      {"admin"},
+	}
+}
+
+type repositories struct {
+	laptop repository.LaptopRepository
+	image  repository.ImageRepository
+	rating repository.RatingRepository
+	review repository.ReviewRepository
+	user   repository.UserRepository
+	// closers flush the persistent repositories.
+	closers []func() error
+}
+
+// openRepositories keeps the data in a database if a DSN is given, in files
+// if a data directory is given and in memory otherwise.
+func openRepositories(dataDir, sync, dbDriver, dbDSN string) (*repositories, error) {
+	if dbDSN != "" {
+		return openSQLRepositories(dbDriver, dbDSN)
+	}
+
+	if dataDir == "" {
+		return &repositories{
+			laptop: repository.NewLaptopRepository(),
+			image:  repository.NewImageRepository(imageFolder),
+			rating: repository.NewRatingRepository(),
+			review: repository.NewReviewRepository(),
+			user:   repository.NewUserRepository(),
+		}, nil
+	}
+
+	policy, err := repository.ParseSyncPolicy(sync)
+	if err != nil {
+		return nil, err
+	}
+	options := repository.FileOptions{Sync: policy}
+
+	repos := &repositories{image: repository.NewImageRepository(imageFolder)}
+	laptopRepo, err := repository.NewFileLaptopRepository(dataDir, options)
+	if err != nil {
+		return nil, err
+	}
+	repos.laptop = laptopRepo
+	repos.closers = append(repos.closers, laptopRepo.Close)
+
+	ratingRepo, err := repository.NewFileRatingRepository(dataDir, options)
+	if err != nil {
+		repos.close()
+		return nil, err
+	}
+	repos.rating = ratingRepo
+	repos.closers = append(repos.closers, ratingRepo.Close)
+
+	reviewRepo, err := repository.NewFileReviewRepository(dataDir, options)
+	if err != nil {
+		repos.close()
+		return nil, err
+	}
+	repos.review = reviewRepo
+	repos.closers = append(repos.closers, reviewRepo.Close)
+
+	userRepo, err := repository.NewFileUserRepository(dataDir, options)
+	if err != nil {
+		repos.close()
+		return nil, err
+	}
+	repos.user = userRepo
+	repos.closers = append(repos.closers, userRepo.Close)
+
+	return repos, nil
+}
+
+func openSQLRepositories(driver, dsn string) (*repositories, error) {
+	dialect, err := repository.ParseSQLDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
 	}
+	if dialect == repository.SQLite {
+		// SQLite allows a single writer, queue the transactions here rather
+		// than fail them as busy
+		db.SetMaxOpenConns(1)
+	}
+
+	err = repository.MigrateSQL(context.Background(), db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &repositories{
+		laptop:  repository.NewSQLLaptopRepository(db, dialect),
+		image:   repository.NewSQLImageRepository(db, dialect, imageFolder),
+		rating:  repository.NewSQLRatingRepository(db, dialect),
+		review:  repository.NewSQLReviewRepository(db, dialect),
+		user:    repository.NewSQLUserRepository(db, dialect),
+		closers: []func() error{db.Close},
+	}, nil
+}
+
+func (r *repositories) close() error {
+	var result error
+	for _, closeRepo := range r.closers {
+		err := closeRepo()
+		if err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
 }
 
 func main() {
 	port := flag.Int("port", 0, "the server port")
+	dataDir := flag.String("data-dir", "", "the directory to persist laptops, ratings, reviews and users in, keep them in memory if empty")
+	walSync := flag.String("wal-sync", "always", "when to fsync the write-ahead log: always, interval or never")
+	dbDriver := flag.String("db-driver", "sqlite", "the database/sql driver of the database, only sqlite is supported")
+	dbDSN := flag.String("db-dsn", "", "the data source name of a database to keep all data in")
+	ratingMin := flag.Float64("rating-min", service.DefaultRatingScale.Min, "the lowest score of a rating")
+	ratingMax := flag.Float64("rating-max", service.DefaultRatingScale.Max, "the highest score of a rating")
+	ratingStep := flag.Float64("rating-step", service.DefaultRatingScale.Step, "the granularity of rating scores, such as 0.5 for half-steps, 0 for any score")
+	priorMean := flag.Float64("rating-prior-mean", repository.DefaultRatingPrior.Mean, "the score expected from a laptop before its ratings when ranking the top rated laptops")
+	priorWeight := flag.Float64("rating-prior-weight", repository.DefaultRatingPrior.Weight, "the number of ratings the prior mean counts as, 0 ranks by the plain average")
+	pageTokenKey := flag.String("page-token-key", "", "the key to sign page tokens with, shared by all servers so their tokens outlive a restart; a random key per process if empty")
 	flag.Parse()
 	log.Printf("start server on port %d", *port)
 
-	userRepo := repository.NewUserRepository()
-	err := seedUsers(userRepo)
+	ratingScale := service.RatingScale{Min: *ratingMin, Max: *ratingMax, Step: *ratingStep}
+	if err := ratingScale.Validate(); err != nil {
+		log.Fatal("invalid rating scale: ", err)
+	}
+
+	ratingPrior := repository.RatingPrior{Mean: *priorMean, Weight: *priorWeight}
+	if err := ratingPrior.Validate(); err != nil {
+		log.Fatal("invalid rating prior: ", err)
+	}
+
+	repos, err := openRepositories(*dataDir, *walSync, *dbDriver, *dbDSN)
+	if err != nil {
+		log.Fatal("cannot open repositories: ", err)
+	}
+
+	err = repos.rating.SetPrior(ratingPrior)
+	if err != nil {
+		log.Fatal("cannot rank ratings: ", err)
+	}
+
+	userRepo := repos.user
+	err = seedUsers(userRepo)
 	if err != nil {
 		log.Fatal("cannot seed users")
 	}
@@ -59,15 +229,23 @@ func main() {
 	tokenMaker := service.NewJWTService(secretKey, tokenDuration)
 	authServer := service.NewAuthService(userRepo, tokenMaker)
 
-	laptopRepo := repository.NewLaptopRepository()
-	imageRepo := repository.NewImageRepository("img")
-	ratingRepo := repository.NewRatingRepository()
-	laptopServer := service.NewLaptopService(laptopRepo, imageRepo, ratingRepo)
+	laptopRepo := repos.laptop
+	imageRepo := repos.image
+	ratingRepo := repos.rating
+	reviewRepo := repos.review
+	laptopServer := service.NewLaptopService(laptopRepo, imageRepo, ratingRepo, reviewRepo)
+	laptopServer.RatingScale = ratingScale
+	if *pageTokenKey == "" {
+		log.Print("page tokens are signed with a random key and expire when the server stops")
+	}
+	laptopServer.SetPageTokenKey([]byte(*pageTokenKey))
 
+	// requests are authorized before they are validated
 	interceptor := middleware.NewAuthMiddleware(tokenMaker, accessibleRoles())
+	validation := middleware.NewValidationMiddleware()
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(interceptor.Unary()),
-		grpc.StreamInterceptor(interceptor.Stream()),
+		grpc.ChainUnaryInterceptor(interceptor.Unary(), validation.Unary()),
+		grpc.ChainStreamInterceptor(interceptor.Stream(), validation.Stream()),
 	)
 
 	proto.RegisterAuthServiceServer(grpcServer, authServer)
@@ -81,8 +259,21 @@ func main() {
 
 	reflection.Register(grpcServer)
 
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Print("stop server")
+		grpcServer.GracefulStop()
+	}()
+
 	err = grpcServer.Serve(listener)
 	if err != nil {
 		log.Fatal("cannot start server: ", err)
 	}
+
+	err = repos.close()
+	if err != nil {
+		log.Fatal("cannot close repositories: ", err)
+	}
 }
@@ -10,6 +10,12 @@ import (
 	"log"
 )
 
+// UserFromContext returns the claims of the user authenticated by the
+// AuthMiddleware, or false for methods that need no access token.
+func UserFromContext(ctx context.Context) (*service.UserClaims, bool) {
+	return service.UserFromContext(ctx)
+}
+
 type AuthMiddleware struct {
 	tokenMaker      *service.JWT
 	accessibleRoles map[string][]string
@@ -26,7 +32,7 @@ func (m *AuthMiddleware) Unary() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		log.Println("--> unary interceptor: ", info.FullMethod)
 
-		err := m.authorize(ctx, info.FullMethod)
+		ctx, err := m.authorize(ctx, info.FullMethod)
 		if err != nil {
 			return nil, err
 		}
@@ -37,41 +43,54 @@ func (m *AuthMiddleware) Unary() grpc.UnaryServerInterceptor {
 func (m *AuthMiddleware) Stream() grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		log.Println("--> stream interceptor: ", info.FullMethod)
-		err := m.authorize(ss.Context(), info.FullMethod)
+		ctx, err := m.authorize(ss.Context(), info.FullMethod)
 		if err != nil {
 			return err
 		}
-		return handler(srv, ss)
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
 	}
 }
 
-func (m *AuthMiddleware) authorize(ctx context.Context, method string) error {
+// authenticatedStream gives the handler the context with the claims of the
+// authenticated user.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authorize returns the context of the RPC with the claims of the
+// authenticated user, methods open to everyone keep their context.
+func (m *AuthMiddleware) authorize(ctx context.Context, method string) (context.Context, error) {
 	accessibleRoles, ok := m.accessibleRoles[method]
 	if !ok {
-		return nil
+		return ctx, nil
 	}
 
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return status.Errorf(codes.Unauthenticated, "metadata is not provided")
+		return nil, status.Errorf(codes.Unauthenticated, "metadata is not provided")
 	}
 
 	value := md["authorization"]
 	if len(value) == 0 {
-		return status.Errorf(codes.Unauthenticated, "authorization token is not provided")
+		return nil, status.Errorf(codes.Unauthenticated, "authorization token is not provided")
 	}
 
 	accessToken := value[0]
 	claims, err := m.tokenMaker.Verify(accessToken)
 	if err != nil {
-		return status.Errorf(codes.Unauthenticated, "access token is invalid: %v", err)
+		return nil, status.Errorf(codes.Unauthenticated, "access token is invalid: %v", err)
 	}
 
 	for _, role := range accessibleRoles {
 		if role == claims.Role {
-			return nil
+			return service.ContextWithUser(ctx, claims), nil
 		}
 	}
 
-	return status.Errorf(codes.PermissionDenied, "no permission to access this RPC")
+	return nil, status.Errorf(codes.PermissionDenied, "no permission to access this RPC")
 }
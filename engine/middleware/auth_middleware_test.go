@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/model/entity"
+	"gitlab.com/iruldev/grpc-class/engine/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+const (
+	protectedMethod = "/grpc.class.LaptopService/RateLaptop"
+	openMethod      = "/grpc.class.LaptopService/SearchLaptop"
+)
+
+func newTestAuthMiddleware(t *testing.T) (*AuthMiddleware, func(role string) context.Context) {
+	tokenMaker := service.NewJWTService("secret", time.Minute)
+	m := NewAuthMiddleware(tokenMaker, map[string][]string{protectedMethod: {"user"}})
+
+	withToken := func(role string) context.Context {
+		token, err := tokenMaker.Generate(&entity.User{Username: "alice", Role: role})
+		require.NoError(t, err)
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", token))
+	}
+	return m, withToken
+}
+
+func TestAuthUnary(t *testing.T) {
+	t.Parallel()
+
+	m, withToken := newTestAuthMiddleware(t)
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		method   string
+		code     codes.Code
+		username string
+	}{
+		{"authenticated", withToken("user"), protectedMethod, codes.OK, "alice"},
+		{"no_token", context.Background(), protectedMethod, codes.Unauthenticated, ""},
+		{"wrong_role", withToken("guest"), protectedMethod, codes.PermissionDenied, ""},
+		{"open", withToken("user"), openMethod, codes.OK, ""},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var username string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if claims, ok := UserFromContext(ctx); ok {
+					username = claims.Username
+				}
+				return nil, nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			_, err := m.Unary()(tc.ctx, nil, info, handler)
+			require.Equal(t, tc.code, status.Code(err))
+			require.Equal(t, tc.username, username)
+		})
+	}
+}
+
+func TestAuthStream(t *testing.T) {
+	t.Parallel()
+
+	m, withToken := newTestAuthMiddleware(t)
+	stream := &contextServerStream{ctx: withToken("user")}
+
+	var claims *service.UserClaims
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		claims, _ = UserFromContext(ss.Context())
+		return nil
+	}
+
+	err := m.Stream()(nil, stream, &grpc.StreamServerInfo{FullMethod: protectedMethod}, handler)
+	require.NoError(t, err)
+	require.NotNil(t, claims)
+	require.Equal(t, "alice", claims.Username)
+	require.Equal(t, "user", claims.Role)
+}
+
+// contextServerStream is a stream of the given context.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"gitlab.com/iruldev/grpc-class/engine/validator"
+	"google.golang.org/grpc"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// ValidationMiddleware rejects requests that break the rules declared on
+// their fields before they reach the handlers.
+type ValidationMiddleware struct{}
+
+func NewValidationMiddleware() *ValidationMiddleware {
+	return &ValidationMiddleware{}
+}
+
+func (m *ValidationMiddleware) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		err := validate(req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (m *ValidationMiddleware) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss})
+	}
+}
+
+// validatingStream validates every message the handler receives.
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil {
+		return err
+	}
+	return validate(m)
+}
+
+func validate(req interface{}) error {
+	msg, ok := req.(protobuf.Message)
+	if !ok {
+		return nil
+	}
+	return validator.Validate(msg)
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/engine/service"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"gitlab.com/iruldev/grpc-class/sample"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"net"
+	"testing"
+)
+
+func TestValidationUnary(t *testing.T) {
+	t.Parallel()
+
+	interceptor := NewValidationMiddleware().Unary()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &proto.CreateLaptopResponse{}, nil
+	}
+
+	invalid := sample.NewLaptop()
+	invalid.Ram.Value = 0
+
+	testCases := []struct {
+		name string
+		req  interface{}
+		code codes.Code
+	}{
+		{"valid", &proto.CreateLaptopRequest{Laptop: sample.NewLaptop()}, codes.OK},
+		{"invalid", &proto.CreateLaptopRequest{Laptop: invalid}, codes.InvalidArgument},
+		{"not_proto", "request", codes.OK},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := interceptor(context.Background(), tc.req, &grpc.UnaryServerInfo{}, handler)
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
+
+func TestValidationStream(t *testing.T) {
+	t.Parallel()
+
+	requests := []*proto.UploadImageRequest{
+		{Data: &proto.UploadImageRequest_Info{Info: &proto.ImageInfo{LaptopId: "id", ImageType: ".png"}}},
+		{Data: &proto.UploadImageRequest_Info{Info: &proto.ImageInfo{LaptopId: "id", ImageType: "png"}}},
+	}
+	stream := &fakeServerStream{requests: requests}
+
+	var received []codes.Code
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		for range requests {
+			req := &proto.UploadImageRequest{}
+			received = append(received, status.Code(ss.RecvMsg(req)))
+		}
+		return nil
+	}
+
+	err := NewValidationMiddleware().Stream()(nil, stream, &grpc.StreamServerInfo{}, handler)
+	require.NoError(t, err)
+	require.Equal(t, []codes.Code{codes.OK, codes.InvalidArgument}, received)
+}
+
+func TestValidationUploadImage(t *testing.T) {
+	t.Parallel()
+
+	laptopServer := service.NewLaptopService(repository.NewLaptopRepository(), nil, nil, nil)
+	grpcServer := grpc.NewServer(grpc.StreamInterceptor(NewValidationMiddleware().Stream()))
+	proto.RegisterLaptopServiceServer(grpcServer, laptopServer)
+
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := proto.NewLaptopServiceClient(conn)
+
+	testCases := []struct {
+		name      string
+		imageType string
+		code      codes.Code
+	}{
+		{"invalid", "png", codes.InvalidArgument},
+		// a valid request reaches the handler, which knows no laptop
+		{"valid", ".png", codes.NotFound},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			stream, err := client.UploadImage(context.Background())
+			require.NoError(t, err)
+
+			info := &proto.ImageInfo{LaptopId: "missing", ImageType: tc.imageType}
+			err = stream.Send(&proto.UploadImageRequest{Data: &proto.UploadImageRequest_Info{Info: info}})
+			require.NoError(t, err)
+
+			_, err = stream.CloseAndRecv()
+			st := status.Convert(err)
+			require.Equal(t, tc.code, st.Code(), st.Message())
+			if tc.code != codes.InvalidArgument {
+				return
+			}
+
+			require.Len(t, st.Details(), 1)
+			badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+			require.True(t, ok)
+			require.Equal(t, "info.image_type", badRequest.GetFieldViolations()[0].GetField())
+		})
+	}
+}
+
+// fakeServerStream receives the given requests in order.
+type fakeServerStream struct {
+	grpc.ServerStream
+	requests []*proto.UploadImageRequest
+}
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+	protobuf.Merge(m.(protobuf.Message), s.requests[0])
+	s.requests = s.requests[1:]
+	return nil
+}
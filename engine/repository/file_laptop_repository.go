@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	protobuf "google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+)
+
+// Fields of a laptop log record. A record holds either the stored laptop
+// after a save or an update, or the ID of a deleted laptop.
+const (
+	laptopRecordPut     protowire.Number = 1
+	laptopRecordDeleted protowire.Number = 2
+)
+
+// FileLaptopRepository keeps the laptops in memory like LaptopRepositoryImpl
+// and persists every change to a write-ahead log in a data directory, so the
+// laptops survive a restart. Reads are served from memory.
+type FileLaptopRepository struct {
+	*LaptopRepositoryImpl
+
+	// mutex serializes the changes, so the log has them in memory order.
+	mutex sync.Mutex
+	wal   *writeAheadLog
+}
+
+// NewFileLaptopRepository loads the laptops stored in dir, creating it if
+// needed. Close must be called to flush the log.
+func NewFileLaptopRepository(dir string, options FileOptions) (*FileLaptopRepository, error) {
+	r := &FileLaptopRepository{LaptopRepositoryImpl: newLaptopRepositoryImpl()}
+
+	var err error
+	r.wal, err = openWriteAheadLog(dir, "laptops", options, r.replay)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *FileLaptopRepository) replay(record []byte) error {
+	laptop, deleted, err := decodeLaptopRecord(record)
+	if err != nil {
+		return err
+	}
+
+	if laptop != nil {
+		r.put(laptop)
+	} else {
+		r.remove(deleted)
+	}
+	return nil
+}
+
+func (r *FileLaptopRepository) Save(laptop *proto.Laptop) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.LaptopRepositoryImpl.Save(laptop)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(r.snapshot(laptop.Id), "")
+	if err != nil {
+		r.remove(laptop.Id)
+		return err
+	}
+
+	return nil
+}
+
+func (r *FileLaptopRepository) Update(laptop *proto.Laptop) (*proto.Laptop, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.snapshot(laptop.Id)
+	updated, err := r.LaptopRepositoryImpl.Update(laptop)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.write(updated, "")
+	if err != nil {
+		r.put(previous)
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (r *FileLaptopRepository) Delete(id string, version uint64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.snapshot(id)
+	err := r.LaptopRepositoryImpl.Delete(id, version)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(nil, id)
+	if err != nil {
+		r.put(previous)
+		return err
+	}
+
+	return nil
+}
+
+// write appends a change to the log and compacts the log once it is long
+// enough. The caller must hold the mutex.
+func (r *FileLaptopRepository) write(laptop *proto.Laptop, deleted string) error {
+	record, err := encodeLaptopRecord(laptop, deleted)
+	if err != nil {
+		return err
+	}
+
+	err = r.wal.append(record)
+	if err != nil {
+		return err
+	}
+
+	if r.wal.needsCompaction() {
+		// the change is durable already, a failed compaction is retried
+		// with the next one
+		err = r.compact()
+		if err != nil {
+			log.Print("cannot compact laptop log: ", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *FileLaptopRepository) compact() error {
+	return r.wal.compact(func(add func(record []byte) error) error {
+		return r.List(context.Background(), func(laptop *proto.Laptop) error {
+			record, err := encodeLaptopRecord(laptop, "")
+			if err != nil {
+				return err
+			}
+			return add(record)
+		})
+	})
+}
+
+// Close flushes the log and releases the files.
+func (r *FileLaptopRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.wal.close()
+}
+
+func encodeLaptopRecord(laptop *proto.Laptop, deleted string) ([]byte, error) {
+	if laptop == nil {
+		record := protowire.AppendTag(nil, laptopRecordDeleted, protowire.BytesType)
+		return protowire.AppendString(record, deleted), nil
+	}
+
+	data, err := protobuf.Marshal(laptop)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal laptop: %w", err)
+	}
+
+	record := protowire.AppendTag(nil, laptopRecordPut, protowire.BytesType)
+	return protowire.AppendBytes(record, data), nil
+}
+
+func decodeLaptopRecord(record []byte) (*proto.Laptop, string, error) {
+	number, typ, n := protowire.ConsumeTag(record)
+	if n < 0 || typ != protowire.BytesType {
+		return nil, "", errors.New("invalid laptop record")
+	}
+
+	value, m := protowire.ConsumeBytes(record[n:])
+	if m < 0 || n+m != len(record) {
+		return nil, "", errors.New("invalid laptop record")
+	}
+
+	switch number {
+	case laptopRecordPut:
+		laptop := &proto.Laptop{}
+		err := protobuf.Unmarshal(value, laptop)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot unmarshal laptop: %w", err)
+		}
+		return laptop, "", nil
+	case laptopRecordDeleted:
+		return nil, string(value), nil
+	default:
+		return nil, "", fmt.Errorf("unknown laptop record field %d", number)
+	}
+}
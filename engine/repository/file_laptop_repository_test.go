@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"gitlab.com/iruldev/grpc-class/sample"
+	protobuf "google.golang.org/protobuf/proto"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func listLaptops(t *testing.T, repo LaptopRepository) map[string]*proto.Laptop {
+	laptops := make(map[string]*proto.Laptop)
+	err := repo.List(context.Background(), func(laptop *proto.Laptop) error {
+		laptops[laptop.GetId()] = laptop
+		return nil
+	})
+	require.NoError(t, err)
+	return laptops
+}
+
+func requireSameLaptops(t *testing.T, expected, actual map[string]*proto.Laptop) {
+	require.Len(t, actual, len(expected))
+	for id, laptop := range expected {
+		require.Contains(t, actual, id)
+		require.True(t, protobuf.Equal(laptop, actual[id]), id)
+	}
+}
+
+func TestFileLaptopRepositoryReplay(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		options FileOptions
+	}{
+		{"sync_always", FileOptions{Sync: SyncAlways}},
+		{"sync_interval", FileOptions{Sync: SyncInterval}},
+		{"sync_never_with_compaction", FileOptions{Sync: SyncNever, CompactAfter: 7}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			repo, err := NewFileLaptopRepository(dir, tc.options)
+			require.NoError(t, err)
+
+			var ids []string
+			for i := 0; i < 20; i++ {
+				laptop := sample.NewLaptop()
+				require.NoError(t, repo.Save(laptop))
+				ids = append(ids, laptop.GetId())
+			}
+
+			for i, id := range ids {
+				switch i % 4 {
+				case 0:
+					laptop, err := repo.Find(id)
+					require.NoError(t, err)
+					laptop.PriceUsd = 999
+					_, err = repo.Update(laptop)
+					require.NoError(t, err)
+				case 1:
+					require.NoError(t, repo.Delete(id, 0))
+				}
+			}
+
+			expected := listLaptops(t, repo)
+			require.NoError(t, repo.Close())
+
+			repo, err = NewFileLaptopRepository(dir, tc.options)
+			require.NoError(t, err)
+			defer repo.Close()
+
+			requireSameLaptops(t, expected, listLaptops(t, repo))
+
+			// the indexes are rebuilt as well
+			maxPrice := 999.0
+			var found []string
+			err = repo.Search(context.Background(), &proto.Filter{MaxPriceUsd: &maxPrice}, SearchOptions{}, func(laptop *proto.Laptop) error {
+				found = append(found, laptop.GetId())
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, found, 5)
+
+			// versions continue where they left off
+			laptop, err := repo.Find(ids[0])
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), laptop.GetVersion())
+			require.ErrorIs(t, repo.Save(laptop), ErrAlreadyExists)
+		})
+	}
+}
+
+func TestFileLaptopRepositoryCompaction(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := NewFileLaptopRepository(dir, FileOptions{CompactAfter: 10})
+	require.NoError(t, err)
+
+	for i := 0; i < 25; i++ {
+		require.NoError(t, repo.Save(sample.NewLaptop()))
+	}
+	expected := listLaptops(t, repo)
+	require.NoError(t, repo.Close())
+
+	require.FileExists(t, filepath.Join(dir, "laptops.snapshot"))
+	require.NoFileExists(t, filepath.Join(dir, "laptops.snapshot.tmp"))
+
+	var records int
+	file, err := os.Open(filepath.Join(dir, "laptops.wal"))
+	require.NoError(t, err)
+	_, err = readRecords(file, func(record []byte) error {
+		records++
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.Equal(t, 5, records)
+
+	repo, err = NewFileLaptopRepository(dir, FileOptions{})
+	require.NoError(t, err)
+	defer repo.Close()
+
+	requireSameLaptops(t, expected, listLaptops(t, repo))
+}
+
+func TestFileLaptopRepositoryIncompleteRecord(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := NewFileLaptopRepository(dir, FileOptions{})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, repo.Save(sample.NewLaptop()))
+	}
+	expected := listLaptops(t, repo)
+	require.NoError(t, repo.Close())
+
+	// simulate a crash in the middle of writing a record
+	path := filepath.Join(dir, "laptops.wal")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.Write(frameRecord([]byte("truncated record"))[:8])
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	repo, err = NewFileLaptopRepository(dir, FileOptions{})
+	require.NoError(t, err)
+	requireSameLaptops(t, expected, listLaptops(t, repo))
+
+	// the incomplete record is dropped, so new records follow the last good one
+	laptop := sample.NewLaptop()
+	require.NoError(t, repo.Save(laptop))
+	expected = listLaptops(t, repo)
+	require.NoError(t, repo.Close())
+
+	repo, err = NewFileLaptopRepository(dir, FileOptions{})
+	require.NoError(t, err)
+	defer repo.Close()
+
+	requireSameLaptops(t, expected, listLaptops(t, repo))
+}
+
+func TestFileLaptopRepositoryCorruptedRecordLength(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := NewFileLaptopRepository(dir, FileOptions{})
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(sample.NewLaptop()))
+	require.NoError(t, repo.Close())
+
+	// a length close to 2^63 must not be allocated
+	path := filepath.Join(dir, "laptops.wal")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	repo, err = NewFileLaptopRepository(dir, FileOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "corrupted")
+	require.Nil(t, repo)
+}
+
+func TestFileLaptopRepositorySyncFailure(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// failures is the number of syncs that fail, the first one syncs
+		// the failed record and the second one its removal
+		failures int
+		usable   bool
+	}{
+		{"record_removed", 1, true},
+		{"log_failed", 2, false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			repo, err := NewFileLaptopRepository(dir, FileOptions{})
+			require.NoError(t, err)
+			require.NoError(t, repo.Save(sample.NewLaptop()))
+
+			failures := tc.failures
+			repo.wal.syncFile = func(file *os.File) error {
+				if failures > 0 {
+					failures--
+					return errors.New("disk failure")
+				}
+				return file.Sync()
+			}
+
+			failed := sample.NewLaptop()
+			require.Error(t, repo.Save(failed))
+			_, err = repo.Find(failed.GetId())
+			require.ErrorIs(t, err, ErrNotFound)
+
+			err = repo.Save(sample.NewLaptop())
+			if tc.usable {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+			expected := listLaptops(t, repo)
+			require.NoError(t, repo.Close())
+
+			// the laptop reported as failed is not replayed
+			repo, err = NewFileLaptopRepository(dir, FileOptions{})
+			require.NoError(t, err)
+			defer repo.Close()
+
+			requireSameLaptops(t, expected, listLaptops(t, repo))
+		})
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	t.Parallel()
+
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		parsed, err := ParseSyncPolicy(policy.String())
+		require.NoError(t, err)
+		require.Equal(t, policy, parsed)
+	}
+
+	_, err := ParseSyncPolicy("sometimes")
+	require.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ratingRecord is the log record of the score of a user for a laptop, or of
+// its retraction.
+type ratingRecord struct {
+	LaptopID  string  `json:"laptop_id"`
+	Username  string  `json:"username"`
+	Score     float64 `json:"score"`
+	Retracted bool    `json:"retracted,omitempty"`
+}
+
+// FileRatingRepository keeps the ratings in memory like RatingRepositoryImpl
+// and persists every change to a write-ahead log in a data directory.
+type FileRatingRepository struct {
+	*RatingRepositoryImpl
+
+	mutex sync.Mutex
+	wal   *writeAheadLog
+}
+
+// NewFileRatingRepository loads the ratings stored in dir, creating it if
+// needed. Close must be called to flush the log.
+func NewFileRatingRepository(dir string, options FileOptions) (*FileRatingRepository, error) {
+	r := &FileRatingRepository{RatingRepositoryImpl: newRatingRepositoryImpl()}
+
+	var err error
+	r.wal, err = openWriteAheadLog(dir, "ratings", options, r.replay)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *FileRatingRepository) replay(data []byte) error {
+	var record ratingRecord
+	err := json.Unmarshal(data, &record)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal rating: %w", err)
+	}
+
+	if record.Retracted {
+		r.RatingRepositoryImpl.Retract(record.LaptopID, record.Username)
+		return nil
+	}
+
+	_, err = r.RatingRepositoryImpl.Rate(record.LaptopID, record.Username, record.Score)
+	return err
+}
+
+func (r *FileRatingRepository) Rate(laptopID, username string, score float64) (*Rating, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, replaced := r.score(laptopID, username)
+	rating, err := r.RatingRepositoryImpl.Rate(laptopID, username, score)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.write(ratingRecord{LaptopID: laptopID, Username: username, Score: score})
+	if err != nil {
+		r.restore(laptopID, username, previous, replaced)
+		return nil, err
+	}
+
+	return rating, nil
+}
+
+func (r *FileRatingRepository) Retract(laptopID, username string) (*Rating, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous, _ := r.score(laptopID, username)
+	rating, err := r.RatingRepositoryImpl.Retract(laptopID, username)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.write(ratingRecord{LaptopID: laptopID, Username: username, Retracted: true})
+	if err != nil {
+		r.restore(laptopID, username, previous, true)
+		return nil, err
+	}
+
+	return rating, nil
+}
+
+// restore puts back the score a user had before a change that could not be
+// written.
+func (r *FileRatingRepository) restore(laptopID, username string, score float64, rated bool) {
+	if rated {
+		r.RatingRepositoryImpl.Rate(laptopID, username, score)
+	} else {
+		r.RatingRepositoryImpl.Retract(laptopID, username)
+	}
+}
+
+// write appends a record to the log and compacts the log once it is long
+// enough. The caller must hold the mutex.
+func (r *FileRatingRepository) write(record ratingRecord) error {
+	data, err := encodeRatingRecord(record)
+	if err != nil {
+		return err
+	}
+
+	err = r.wal.append(data)
+	if err != nil {
+		return err
+	}
+
+	if r.wal.needsCompaction() {
+		err = r.wal.compact(func(add func(record []byte) error) error {
+			return r.each(func(laptopID, username string, score float64) error {
+				data, err := encodeRatingRecord(ratingRecord{LaptopID: laptopID, Username: username, Score: score})
+				if err != nil {
+					return err
+				}
+				return add(data)
+			})
+		})
+		if err != nil {
+			log.Print("cannot compact rating log: ", err)
+		}
+	}
+
+	return nil
+}
+
+// Close flushes the log and releases the files.
+func (r *FileRatingRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.wal.close()
+}
+
+func encodeRatingRecord(record ratingRecord) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal rating: %w", err)
+	}
+	return data, nil
+}
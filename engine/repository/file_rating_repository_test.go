@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestFileRatingRepository(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	options := FileOptions{CompactAfter: 4}
+	repo, err := NewFileRatingRepository(dir, options)
+	require.NoError(t, err)
+
+	scores := map[string][]float64{
+		"laptop-1": {5, 3, 4},
+		"laptop-2": {1},
+		"laptop-3": {2, 2, 2, 2, 2},
+	}
+	for laptopID, laptopScores := range scores {
+		for i, score := range laptopScores {
+			_, err := repo.Rate(laptopID, fmt.Sprintf("user-%d", i), score)
+			require.NoError(t, err)
+		}
+	}
+
+	// replacements and retractions are replayed in order
+	_, err = repo.Rate("laptop-1", "user-1", 7)
+	require.NoError(t, err)
+	_, err = repo.Retract("laptop-3", "user-4")
+	require.NoError(t, err)
+	_, err = repo.Retract("laptop-2", "user-0")
+	require.NoError(t, err)
+
+	// the returned rating is a copy
+	rating, err := repo.Rate("laptop-2", "user-1", 9)
+	require.NoError(t, err)
+	rating.Count = 100
+	require.NoError(t, repo.Close())
+
+	repo, err = NewFileRatingRepository(dir, options)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	expected := map[string]Rating{
+		"laptop-1": {Count: 3, Sum: 16, Min: 4, Max: 7, Histogram: map[int]uint32{4: 1, 5: 1, 7: 1}},
+		"laptop-2": {Count: 1, Sum: 9, Min: 9, Max: 9, Histogram: map[int]uint32{9: 1}},
+		"laptop-3": {Count: 4, Sum: 8, Min: 2, Max: 2, Histogram: map[int]uint32{2: 4}},
+	}
+	for laptopID, want := range expected {
+		rating, err := repo.Find(laptopID)
+		require.NoError(t, err)
+		require.Equal(t, want, *rating)
+	}
+
+	_, err = repo.Find("laptop-4")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileRatingRepositoryRejectsNonFiniteScore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := NewFileRatingRepository(dir, FileOptions{})
+	require.NoError(t, err)
+
+	_, err = repo.Rate("laptop-1", "alice", math.NaN())
+	require.Error(t, err)
+	_, err = repo.Rate("laptop-1", "bob", 3)
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	// nothing unreadable was written to the log
+	repo, err = NewFileRatingRepository(dir, FileOptions{})
+	require.NoError(t, err)
+	defer repo.Close()
+
+	rating, err := repo.Find("laptop-1")
+	require.NoError(t, err)
+	require.Equal(t, Rating{Count: 1, Sum: 3, Min: 3, Max: 3, Histogram: map[int]uint32{3: 1}}, *rating)
+}
+
+func TestEncodeRatingRecord(t *testing.T) {
+	t.Parallel()
+
+	_, err := encodeRatingRecord(ratingRecord{LaptopID: "laptop-1", Username: "alice", Score: math.Inf(1)})
+	require.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	protobuf "google.golang.org/protobuf/proto"
+	"log"
+	"sync"
+)
+
+// Fields of a review log record. A record holds either the stored review
+// after a save or an update, or the ID of a deleted review.
+const (
+	reviewRecordPut     protowire.Number = 1
+	reviewRecordDeleted protowire.Number = 2
+)
+
+// FileReviewRepository keeps the reviews in memory like ReviewRepositoryImpl
+// and persists every change to a write-ahead log in a data directory.
+type FileReviewRepository struct {
+	*ReviewRepositoryImpl
+
+	mutex sync.Mutex
+	wal   *writeAheadLog
+}
+
+// NewFileReviewRepository loads the reviews stored in dir, creating it if
+// needed. Close must be called to flush the log.
+func NewFileReviewRepository(dir string, options FileOptions) (*FileReviewRepository, error) {
+	r := &FileReviewRepository{ReviewRepositoryImpl: newReviewRepositoryImpl()}
+
+	var err error
+	r.wal, err = openWriteAheadLog(dir, "reviews", options, r.replay)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *FileReviewRepository) replay(record []byte) error {
+	review, deleted, err := decodeReviewRecord(record)
+	if err != nil {
+		return err
+	}
+
+	if review != nil {
+		r.put(review)
+	} else {
+		r.remove(deleted)
+	}
+	return nil
+}
+
+func (r *FileReviewRepository) Save(review *proto.Review) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.ReviewRepositoryImpl.Save(review)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(r.snapshot(review.Id), "")
+	if err != nil {
+		r.remove(review.Id)
+		return err
+	}
+
+	return nil
+}
+
+func (r *FileReviewRepository) Update(review *proto.Review) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.snapshot(review.Id)
+	err := r.ReviewRepositoryImpl.Update(review)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(r.snapshot(review.Id), "")
+	if err != nil {
+		r.put(previous)
+		return err
+	}
+
+	return nil
+}
+
+func (r *FileReviewRepository) SetHidden(id string, hidden bool) (*proto.Review, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.snapshot(id)
+	review, err := r.ReviewRepositoryImpl.SetHidden(id, hidden)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.write(review, "")
+	if err != nil {
+		r.put(previous)
+		return nil, err
+	}
+
+	return review, nil
+}
+
+func (r *FileReviewRepository) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := r.snapshot(id)
+	err := r.ReviewRepositoryImpl.Delete(id)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(nil, id)
+	if err != nil {
+		r.put(previous)
+		return err
+	}
+
+	return nil
+}
+
+// write appends a change to the log and compacts the log once it is long
+// enough. The caller must hold the mutex.
+func (r *FileReviewRepository) write(review *proto.Review, deleted string) error {
+	record, err := encodeReviewRecord(review, deleted)
+	if err != nil {
+		return err
+	}
+
+	err = r.wal.append(record)
+	if err != nil {
+		return err
+	}
+
+	if r.wal.needsCompaction() {
+		err = r.wal.compact(func(add func(record []byte) error) error {
+			return r.each(func(review *proto.Review) error {
+				record, err := encodeReviewRecord(review, "")
+				if err != nil {
+					return err
+				}
+				return add(record)
+			})
+		})
+		if err != nil {
+			log.Print("cannot compact review log: ", err)
+		}
+	}
+
+	return nil
+}
+
+// Close flushes the log and releases the files.
+func (r *FileReviewRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.wal.close()
+}
+
+func encodeReviewRecord(review *proto.Review, deleted string) ([]byte, error) {
+	if review == nil {
+		record := protowire.AppendTag(nil, reviewRecordDeleted, protowire.BytesType)
+		return protowire.AppendString(record, deleted), nil
+	}
+
+	data, err := protobuf.Marshal(review)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal review: %w", err)
+	}
+
+	record := protowire.AppendTag(nil, reviewRecordPut, protowire.BytesType)
+	return protowire.AppendBytes(record, data), nil
+}
+
+func decodeReviewRecord(record []byte) (*proto.Review, string, error) {
+	number, typ, n := protowire.ConsumeTag(record)
+	if n < 0 || typ != protowire.BytesType {
+		return nil, "", errors.New("invalid review record")
+	}
+
+	value, m := protowire.ConsumeBytes(record[n:])
+	if m < 0 || n+m != len(record) {
+		return nil, "", errors.New("invalid review record")
+	}
+
+	switch number {
+	case reviewRecordPut:
+		review := &proto.Review{}
+		err := protobuf.Unmarshal(value, review)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot unmarshal review: %w", err)
+		}
+		return review, "", nil
+	case reviewRecordDeleted:
+		return nil, string(value), nil
+	default:
+		return nil, "", fmt.Errorf("unknown review record field %d", number)
+	}
+}
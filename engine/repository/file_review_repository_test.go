@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+func TestFileReviewRepository(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	options := FileOptions{CompactAfter: 4}
+	repo, err := NewFileReviewRepository(dir, options)
+	require.NoError(t, err)
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, repo.Save(&proto.Review{
+			Id:        fmt.Sprintf("review-%d", i),
+			LaptopId:  "laptop-1",
+			Author:    fmt.Sprintf("user-%d", i),
+			Title:     "title",
+			Score:     float64(i + 1),
+			CreatedAt: timestamppb.New(createdAt.Add(time.Duration(i) * time.Minute)),
+		}))
+	}
+
+	edited, err := repo.Find("review-1")
+	require.NoError(t, err)
+	edited.Title = "edited"
+	require.NoError(t, repo.Update(edited))
+	_, err = repo.SetHidden("review-1", true)
+	require.NoError(t, err)
+	require.NoError(t, repo.Delete("review-3"))
+	require.NoError(t, repo.Close())
+
+	repo, err = NewFileReviewRepository(dir, options)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	reviews, err := repo.List("laptop-1", ReviewListOptions{IncludeHidden: true})
+	require.NoError(t, err)
+
+	var ids []string
+	for _, review := range reviews {
+		ids = append(ids, review.Id)
+	}
+	require.Equal(t, []string{"review-4", "review-2", "review-1", "review-0"}, ids)
+	require.True(t, reviews[2].Hidden)
+	require.Equal(t, "edited", reviews[2].Title)
+	require.Equal(t, createdAt.Add(4*time.Minute), reviews[0].CreatedAt.AsTime())
+
+	_, err = repo.FindByAuthor("laptop-1", "user-3")
+	require.ErrorIs(t, err, ErrNotFound)
+}
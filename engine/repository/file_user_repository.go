@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/engine/model/entity"
+	"log"
+	"sync"
+)
+
+// userRecord is the log record of a saved user.
+type userRecord struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+	Role           string `json:"role"`
+}
+
+// FileUserRepository keeps the users in memory like UserRepositoryImpl and
+// persists every change to a write-ahead log in a data directory.
+type FileUserRepository struct {
+	*UserRepositoryImpl
+
+	mutex sync.Mutex
+	wal   *writeAheadLog
+}
+
+// NewFileUserRepository loads the users stored in dir, creating it if
+// needed. Close must be called to flush the log.
+func NewFileUserRepository(dir string, options FileOptions) (*FileUserRepository, error) {
+	r := &FileUserRepository{UserRepositoryImpl: newUserRepositoryImpl()}
+
+	var err error
+	r.wal, err = openWriteAheadLog(dir, "users", options, r.replay)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *FileUserRepository) replay(data []byte) error {
+	var record userRecord
+	err := json.Unmarshal(data, &record)
+	if err != nil {
+		return fmt.Errorf("cannot unmarshal user: %w", err)
+	}
+
+	r.put(&entity.User{
+		Username:       record.Username,
+		HashedPassword: record.HashedPassword,
+		Role:           record.Role,
+	})
+	return nil
+}
+
+func (r *FileUserRepository) Save(user *entity.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	err := r.UserRepositoryImpl.Save(user)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(user)
+	if err != nil {
+		r.remove(user.Username)
+		return err
+	}
+
+	return nil
+}
+
+// write appends a user to the log and compacts the log once it is long
+// enough. The caller must hold the mutex.
+func (r *FileUserRepository) write(user *entity.User) error {
+	err := r.wal.append(encodeUserRecord(user))
+	if err != nil {
+		return err
+	}
+
+	if r.wal.needsCompaction() {
+		err = r.wal.compact(func(add func(record []byte) error) error {
+			return r.each(func(user *entity.User) error {
+				return add(encodeUserRecord(user))
+			})
+		})
+		if err != nil {
+			log.Print("cannot compact user log: ", err)
+		}
+	}
+
+	return nil
+}
+
+// Close flushes the log and releases the files.
+func (r *FileUserRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.wal.close()
+}
+
+func encodeUserRecord(user *entity.User) []byte {
+	// a struct of strings always marshals
+	data, _ := json.Marshal(userRecord{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		Role:           user.Role,
+	})
+	return data
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/model/entity"
+	"testing"
+)
+
+func TestFileUserRepository(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	repo, err := NewFileUserRepository(dir, FileOptions{})
+	require.NoError(t, err)
+
+	count, err := repo.Count()
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	user, err := entity.NewUser("admin1", "secret", "admin")
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(user))
+	require.ErrorIs(t, repo.Save(user), ErrAlreadyExists)
+	require.NoError(t, repo.Close())
+
+	repo, err = NewFileUserRepository(dir, FileOptions{})
+	require.NoError(t, err)
+	defer repo.Close()
+
+	count, err = repo.Count()
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	found, err := repo.Find("admin1")
+	require.NoError(t, err)
+	require.Equal(t, user, found)
+	require.True(t, found.IsCorrectPassword("secret"))
+}
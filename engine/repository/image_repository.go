@@ -32,31 +32,43 @@ func NewImageRepository(imageFolder string) ImageRepository {
 }
 
 func (r *ImageRepositoryImpl) Save(laptopID, imageType string, imageData bytes.Buffer) (string, error) {
+	imageID, imagePath, err := writeImage(r.imageFolder, imageType, imageData)
+	if err != nil {
+		return "", err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.images[imageID] = &ImageInfo{
+		LaptopID: laptopID,
+		Type:     imageType,
+		Path:     imagePath,
+	}
+
+	return imageID, nil
+}
+
+// writeImage stores the image data in a new file of the image folder and
+// returns the generated image ID and the path of the file.
+func writeImage(imageFolder, imageType string, imageData bytes.Buffer) (string, string, error) {
 	imageID, err := uuid.NewRandom()
 	if err != nil {
-		return "", fmt.Errorf("cannot generate image id: %w", err)
+		return "", "", fmt.Errorf("cannot generate image id: %w", err)
 	}
 
-	imagePath := fmt.Sprintf("%s/%s%s", r.imageFolder, imageID, imageType)
+	imagePath := fmt.Sprintf("%s/%s%s", imageFolder, imageID, imageType)
 
 	file, err := os.Create(imagePath)
 	if err != nil {
-		return "", fmt.Errorf("cannot create image file: %w", err)
+		return "", "", fmt.Errorf("cannot create image file: %w", err)
 	}
+	defer file.Close()
 
 	_, err = imageData.WriteTo(file)
 	if err != nil {
-		return "", fmt.Errorf("cannot write image to file: %w", err)
-	}
-
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-
-	r.images[imageID.String()] = &ImageInfo{
-		LaptopID: laptopID,
-		Type:     imageType,
-		Path:     imagePath,
+		return "", "", fmt.Errorf("cannot write image to file: %w", err)
 	}
 
-	return imageID.String(), nil
+	return imageID.String(), imagePath, nil
 }
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"strconv"
+)
+
+// Facet and range names reported by Facets.
+const (
+	FacetBrand       = "brand"
+	FacetCPUBrand    = "cpu_brand"
+	FacetGPUBrand    = "gpu_brand"
+	FacetRAM         = "ram"
+	FacetPrice       = "price"
+	FacetPanel       = "screen_panel"
+	FacetReleaseYear = "release_year"
+
+	RangePrice       = "price_usd"
+	RangeRAM         = "ram_gb"
+	RangeReleaseYear = "release_year"
+	RangeScreenSize  = "screen_inch"
+	RangeWeight      = "weight_kg"
+)
+
+const priceBucketWidth = 500
+
+// ramBucketBounds are the upper bounds in gigabytes of the RAM buckets.
+var ramBucketBounds = []float64{4, 8, 16, 32, 64}
+
+// Facets summarises a set of laptops for a filter sidebar.
+type Facets struct {
+	Total uint32
+	// Buckets maps a facet name to the number of laptops per bucket. A
+	// laptop with several GPUs of different brands counts once for each.
+	Buckets map[string]map[string]uint32
+	// Ranges maps a numeric field to its smallest and largest value.
+	Ranges map[string]*Range
+}
+
+type Range struct {
+	Min float64
+	Max float64
+}
+
+func newFacets() *Facets {
+	return &Facets{
+		Buckets: make(map[string]map[string]uint32),
+		Ranges:  make(map[string]*Range),
+	}
+}
+
+func (f *Facets) add(laptop *proto.Laptop) {
+	f.Total++
+
+	f.count(FacetBrand, laptop.GetBrand())
+	f.count(FacetCPUBrand, laptop.GetCpu().GetBrand())
+
+	gpuBrands := make(map[string]bool)
+	for _, gpu := range laptop.GetGpus() {
+		if !gpuBrands[gpu.GetBrand()] {
+			gpuBrands[gpu.GetBrand()] = true
+			f.count(FacetGPUBrand, gpu.GetBrand())
+		}
+	}
+
+	ramGB := float64(toBit(laptop.GetRam())) / float64(uint64(1)<<33)
+	f.count(FacetRAM, ramBucket(ramGB))
+
+	lower := int(laptop.GetPriceUsd()/priceBucketWidth) * priceBucketWidth
+	f.count(FacetPrice, fmt.Sprintf("%d-%d", lower, lower+priceBucketWidth))
+
+	f.count(FacetPanel, laptop.GetScreen().GetPanel().String())
+	f.count(FacetReleaseYear, strconv.Itoa(int(laptop.GetReleaseYear())))
+
+	f.extend(RangePrice, laptop.GetPriceUsd())
+	f.extend(RangeRAM, ramGB)
+	f.extend(RangeReleaseYear, float64(laptop.GetReleaseYear()))
+	f.extend(RangeScreenSize, float64(laptop.GetScreen().GetSizeInch()))
+	if kg, ok := weightKg(laptop); ok {
+		f.extend(RangeWeight, kg)
+	}
+}
+
+func (f *Facets) count(facet, bucket string) {
+	buckets := f.Buckets[facet]
+	if buckets == nil {
+		buckets = make(map[string]uint32)
+		f.Buckets[facet] = buckets
+	}
+	buckets[bucket]++
+}
+
+func (f *Facets) extend(name string, value float64) {
+	r := f.Ranges[name]
+	if r == nil {
+		f.Ranges[name] = &Range{Min: value, Max: value}
+		return
+	}
+	if value < r.Min {
+		r.Min = value
+	}
+	if value > r.Max {
+		r.Max = value
+	}
+}
+
+// ramBucket returns the label of the half-open RAM range the size falls in,
+// for example "8-16GB" for 8 <= size < 16.
+func ramBucket(gigabytes float64) string {
+	lower := 0.0
+	for _, upper := range ramBucketBounds {
+		if gigabytes < upper {
+			if lower == 0 {
+				return fmt.Sprintf("<%gGB", upper)
+			}
+			return fmt.Sprintf("%g-%gGB", lower, upper)
+		}
+		lower = upper
+	}
+	return fmt.Sprintf(">=%gGB", lower)
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"gitlab.com/iruldev/grpc-class/proto"
+	"sort"
+)
+
+type indexEntry struct {
+	value float64
+	id    string
+}
+
+func (e indexEntry) less(other indexEntry) bool {
+	return e.value < other.value || (e.value == other.value && e.id < other.id)
+}
+
+// maxIndexBlock is the size at which a block of a sortedIndex is split.
+const maxIndexBlock = 1024
+
+// sortedIndex keeps the laptop IDs ordered by one numeric field, so the
+// laptops within a range of that field are found with binary searches. The
+// entries are kept in a list of small sorted blocks rather than one slice,
+// so an insert or a removal only moves the entries of one block.
+type sortedIndex struct {
+	key    func(laptop *proto.Laptop) float64
+	blocks [][]indexEntry
+}
+
+type indexPosition struct {
+	block int
+	entry int
+}
+
+func newSortedIndex(key func(laptop *proto.Laptop) float64) *sortedIndex {
+	return &sortedIndex{key: key}
+}
+
+// search returns the position of the first entry for which after is true.
+// after must be false for a prefix of the entries and true for the rest.
+func (x *sortedIndex) search(after func(entry indexEntry) bool) indexPosition {
+	b := sort.Search(len(x.blocks), func(b int) bool {
+		block := x.blocks[b]
+		return after(block[len(block)-1])
+	})
+	if b == len(x.blocks) {
+		return indexPosition{block: b}
+	}
+
+	block := x.blocks[b]
+	i := sort.Search(len(block), func(i int) bool { return after(block[i]) })
+	return indexPosition{block: b, entry: i}
+}
+
+func (x *sortedIndex) insert(laptop *proto.Laptop) {
+	entry := indexEntry{value: x.key(laptop), id: laptop.GetId()}
+	if len(x.blocks) == 0 {
+		x.blocks = [][]indexEntry{{entry}}
+		return
+	}
+
+	p := x.search(func(e indexEntry) bool { return !e.less(entry) })
+	if p.block == len(x.blocks) {
+		p.block = len(x.blocks) - 1
+		p.entry = len(x.blocks[p.block])
+	}
+
+	block := append(x.blocks[p.block], indexEntry{})
+	copy(block[p.entry+1:], block[p.entry:])
+	block[p.entry] = entry
+	x.blocks[p.block] = block
+
+	if len(block) > maxIndexBlock {
+		half := len(block) / 2
+		tail := append([]indexEntry(nil), block[half:]...)
+		x.blocks[p.block] = block[:half:half]
+		x.blocks = append(x.blocks, nil)
+		copy(x.blocks[p.block+2:], x.blocks[p.block+1:])
+		x.blocks[p.block+1] = tail
+	}
+}
+
+// remove deletes the entry of a laptop, which must be the version of the
+// laptop that was inserted.
+func (x *sortedIndex) remove(laptop *proto.Laptop) {
+	entry := indexEntry{value: x.key(laptop), id: laptop.GetId()}
+	p := x.search(func(e indexEntry) bool { return !e.less(entry) })
+	if p.block == len(x.blocks) || x.blocks[p.block][p.entry] != entry {
+		return
+	}
+
+	block := x.blocks[p.block]
+	block = append(block[:p.entry], block[p.entry+1:]...)
+	if len(block) > 0 {
+		x.blocks[p.block] = block
+		return
+	}
+	x.blocks = append(x.blocks[:p.block], x.blocks[p.block+1:]...)
+}
+
+// bounds returns the positions of the first entry with min <= value and of
+// the first entry with value > max, a nil bound is open.
+func (x *sortedIndex) bounds(min, max *float64) (indexPosition, indexPosition) {
+	lo, hi := indexPosition{}, indexPosition{block: len(x.blocks)}
+	if min != nil {
+		lo = x.search(func(e indexEntry) bool { return e.value >= *min })
+	}
+	if max != nil {
+		hi = x.search(func(e indexEntry) bool { return e.value > *max })
+	}
+	return lo, hi
+}
+
+// count returns the number of entries between two positions.
+func (x *sortedIndex) count(lo, hi indexPosition) int {
+	if hi.block < lo.block || (hi.block == lo.block && hi.entry <= lo.entry) {
+		return 0
+	}
+
+	n := -lo.entry
+	for b := lo.block; b < hi.block; b++ {
+		n += len(x.blocks[b])
+	}
+	return n + hi.entry
+}
+
+// ids returns the laptop IDs of the entries between two positions.
+func (x *sortedIndex) ids(lo, hi indexPosition) []string {
+	ids := make([]string, 0, x.count(lo, hi))
+	for p := lo; p.block < hi.block || (p.block == hi.block && p.entry < hi.entry); {
+		ids = append(ids, x.blocks[p.block][p.entry].id)
+		p.entry++
+		if p.entry == len(x.blocks[p.block]) {
+			p = indexPosition{block: p.block + 1}
+		}
+	}
+	return ids
+}
+
+// laptopIndexes holds the secondary indexes of the laptop repository.
+type laptopIndexes struct {
+	price       *sortedIndex
+	cpuCores    *sortedIndex
+	cpuGhz      *sortedIndex
+	ram         *sortedIndex
+	releaseYear *sortedIndex
+}
+
+func newLaptopIndexes() *laptopIndexes {
+	return &laptopIndexes{
+		price: newSortedIndex(func(laptop *proto.Laptop) float64 {
+			return laptop.GetPriceUsd()
+		}),
+		cpuCores: newSortedIndex(func(laptop *proto.Laptop) float64 {
+			return float64(laptop.GetCpu().GetNumberCores())
+		}),
+		cpuGhz: newSortedIndex(func(laptop *proto.Laptop) float64 {
+			return laptop.GetCpu().GetMinGhz()
+		}),
+		ram: newSortedIndex(func(laptop *proto.Laptop) float64 {
+			return float64(toBit(laptop.GetRam()))
+		}),
+		releaseYear: newSortedIndex(func(laptop *proto.Laptop) float64 {
+			return float64(laptop.GetReleaseYear())
+		}),
+	}
+}
+
+func (x *laptopIndexes) all() []*sortedIndex {
+	return []*sortedIndex{x.price, x.cpuCores, x.cpuGhz, x.ram, x.releaseYear}
+}
+
+func (x *laptopIndexes) insert(laptop *proto.Laptop) {
+	for _, index := range x.all() {
+		index.insert(laptop)
+	}
+}
+
+func (x *laptopIndexes) remove(laptop *proto.Laptop) {
+	for _, index := range x.all() {
+		index.remove(laptop)
+	}
+}
+
+// candidates returns the IDs of the laptops in the narrowest index range
+// implied by the filter. It returns false if the filter constrains none of
+// the indexed fields, in which case every laptop is a candidate.
+func (x *laptopIndexes) candidates(filter *proto.Filter) ([]string, bool) {
+	if filter == nil {
+		return nil, false
+	}
+
+	var best *sortedIndex
+	var lo, hi indexPosition
+	consider := func(index *sortedIndex, min, max *float64) {
+		if min == nil && max == nil {
+			return
+		}
+		from, to := index.bounds(min, max)
+		if best == nil || index.count(from, to) < best.count(lo, hi) {
+			best, lo, hi = index, from, to
+		}
+	}
+
+	consider(x.price, filter.MinPriceUsd, filter.MaxPriceUsd)
+	if filter.MinCpuCores != nil {
+		cores := float64(filter.GetMinCpuCores())
+		consider(x.cpuCores, &cores, nil)
+	}
+	consider(x.cpuGhz, filter.MinCpuGhz, nil)
+	if filter.MinRam != nil {
+		ram := float64(toBit(filter.GetMinRam()))
+		consider(x.ram, &ram, nil)
+	}
+	var minYear, maxYear *float64
+	if filter.MinReleaseYear != nil {
+		year := float64(filter.GetMinReleaseYear())
+		minYear = &year
+	}
+	if filter.MaxReleaseYear != nil {
+		year := float64(filter.GetMaxReleaseYear())
+		maxYear = &year
+	}
+	consider(x.releaseYear, minYear, maxYear)
+
+	if best == nil {
+		return nil, false
+	}
+	return best.ids(lo, hi), true
+}
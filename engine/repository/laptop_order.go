@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"strings"
+)
+
+type OrderField string
+
+const (
+	OrderByPrice       OrderField = "price"
+	OrderByReleaseYear OrderField = "release_year"
+	OrderByBrand       OrderField = "brand"
+	OrderByUpdatedAt   OrderField = "updated_at"
+	OrderByRating      OrderField = "rating"
+	OrderByCPUCores    OrderField = "cpu_cores"
+	OrderByCPUGhz      OrderField = "cpu_ghz"
+	OrderByRAM         OrderField = "ram"
+	OrderByRelevance   OrderField = "relevance"
+)
+
+type OrderKey struct {
+	Field      OrderField
+	Descending bool
+}
+
+// Order lists the keys laptops are sorted by. The laptop ID is always used as
+// the last, ascending key so that the order is total.
+type Order []OrderKey
+
+// ParseOrder parses an order_by expression such as "price desc, brand".
+func ParseOrder(orderBy string) (Order, error) {
+	var order Order
+	if strings.TrimSpace(orderBy) == "" {
+		return order, nil
+	}
+
+	for _, item := range strings.Split(orderBy, ",") {
+		words := strings.Fields(item)
+		if len(words) == 0 || len(words) > 2 {
+			return nil, fmt.Errorf("invalid order item %q", strings.TrimSpace(item))
+		}
+
+		key := OrderKey{Field: OrderField(words[0])}
+		switch key.Field {
+		case OrderByPrice, OrderByReleaseYear, OrderByBrand, OrderByUpdatedAt, OrderByRating,
+			OrderByCPUCores, OrderByCPUGhz, OrderByRAM, OrderByRelevance:
+		default:
+			return nil, fmt.Errorf("unknown order field %q", words[0])
+		}
+
+		if len(words) == 2 {
+			switch strings.ToLower(words[1]) {
+			case "asc":
+			case "desc":
+				key.Descending = true
+			default:
+				return nil, fmt.Errorf("invalid order direction %q", words[1])
+			}
+		}
+
+		order = append(order, key)
+	}
+
+	return order, nil
+}
+
+// Has reports whether the order has a key on the field.
+func (o Order) Has(field OrderField) bool {
+	for _, key := range o {
+		if key.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the canonical form of the order.
+func (o Order) String() string {
+	items := make([]string, len(o))
+	for i, key := range o {
+		items[i] = string(key.Field)
+		if key.Descending {
+			items[i] += " desc"
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// Values returns the sort values of the laptop followed by its ID. Numbers are
+// returned as float64 and text as string. The rating and relevance functions
+// supply the average score and the text search score of a laptop, either may
+// be nil in which case the score is zero.
+func (o Order) Values(laptop *proto.Laptop, rating, relevance func(laptopID string) float64) []interface{} {
+	values := make([]interface{}, 0, len(o)+1)
+	for _, key := range o {
+		switch key.Field {
+		case OrderByPrice:
+			values = append(values, laptop.GetPriceUsd())
+		case OrderByReleaseYear:
+			values = append(values, float64(laptop.GetReleaseYear()))
+		case OrderByBrand:
+			values = append(values, laptop.GetBrand())
+		case OrderByUpdatedAt:
+			values = append(values, float64(laptop.GetUpdatedAt().AsTime().UnixMicro()))
+		case OrderByRating:
+			values = append(values, score(rating, laptop.GetId()))
+		case OrderByRelevance:
+			values = append(values, score(relevance, laptop.GetId()))
+		case OrderByCPUCores:
+			values = append(values, float64(laptop.GetCpu().GetNumberCores()))
+		case OrderByCPUGhz:
+			values = append(values, laptop.GetCpu().GetMinGhz())
+		case OrderByRAM:
+			values = append(values, float64(toBit(laptop.GetRam())))
+		}
+	}
+	return append(values, laptop.GetId())
+}
+
+func score(scores func(laptopID string) float64, laptopID string) float64 {
+	if scores == nil {
+		return 0
+	}
+	return scores(laptopID)
+}
+
+// CheckValues reports whether values have the shape returned by Values.
+func (o Order) CheckValues(values []interface{}) error {
+	if len(values) != len(o)+1 {
+		return fmt.Errorf("expected %d sort values, got %d", len(o)+1, len(values))
+	}
+
+	for i, value := range values {
+		text := i == len(o) || o[i].Field == OrderByBrand
+		_, isString := value.(string)
+		_, isNumber := value.(float64)
+		if (text && !isString) || (!text && !isNumber) {
+			return fmt.Errorf("invalid sort value %v at position %d", value, i)
+		}
+	}
+
+	return nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether the laptop with values a
+// sorts before, together with or after the laptop with values b.
+func (o Order) Compare(a, b []interface{}) int {
+	for i := range a {
+		c := compareValues(a[i], b[i])
+		if i < len(o) && o[i].Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+type orderedLaptop struct {
+	laptop *proto.Laptop
+	values []interface{}
+}
+
+// laptopHeap is a max-heap: the laptop that sorts last is on top, so the
+// worst of the K best laptops seen so far can be replaced in O(log K).
+type laptopHeap struct {
+	order Order
+	items []orderedLaptop
+}
+
+func (h *laptopHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *laptopHeap) Less(i, j int) bool {
+	return h.order.Compare(h.items[i].values, h.items[j].values) > 0
+}
+
+func (h *laptopHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *laptopHeap) Push(x interface{}) {
+	h.items = append(h.items, x.(orderedLaptop))
+}
+
+func (h *laptopHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
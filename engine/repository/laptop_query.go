@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"strings"
+)
+
+// Query is a parsed boolean expression over laptop fields, for example
+// `brand in (Dell, Lenovo) and (ram >= 16GB or gpu.memory >= 6GB)`.
+type Query interface {
+	Match(laptop *proto.Laptop) bool
+}
+
+// QueryError reports an invalid query. Pos is the 1-based position of the
+// offending character in the query text.
+type QueryError struct {
+	Pos int
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+type valueKind int
+
+const (
+	kindNumber valueKind = iota
+	kindString
+	kindBool
+	kindMemory
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	case kindBool:
+		return "bool"
+	default:
+		return "memory"
+	}
+}
+
+// queryField extracts the values of a laptop field. Fields of repeated
+// messages such as gpu.brand have one value per element and match when any
+// of them does. Numbers and memory sizes (in bits) are float64, text is a
+// string and flags are bool.
+type queryField struct {
+	kind   valueKind
+	values func(laptop *proto.Laptop) []interface{}
+}
+
+func one(value interface{}) []interface{} {
+	return []interface{}{value}
+}
+
+func eachGPU(value func(gpu *proto.GPU) interface{}) func(laptop *proto.Laptop) []interface{} {
+	return func(laptop *proto.Laptop) []interface{} {
+		values := make([]interface{}, len(laptop.GetGpus()))
+		for i, gpu := range laptop.GetGpus() {
+			values[i] = value(gpu)
+		}
+		return values
+	}
+}
+
+var queryFields = map[string]queryField{
+	"brand":         {kindString, func(l *proto.Laptop) []interface{} { return one(l.GetBrand()) }},
+	"name":          {kindString, func(l *proto.Laptop) []interface{} { return one(l.GetName()) }},
+	"price":         {kindNumber, func(l *proto.Laptop) []interface{} { return one(l.GetPriceUsd()) }},
+	"release_year":  {kindNumber, func(l *proto.Laptop) []interface{} { return one(float64(l.GetReleaseYear())) }},
+	"cpu.brand":     {kindString, func(l *proto.Laptop) []interface{} { return one(l.GetCpu().GetBrand()) }},
+	"cpu.name":      {kindString, func(l *proto.Laptop) []interface{} { return one(l.GetCpu().GetName()) }},
+	"cpu.cores":     {kindNumber, func(l *proto.Laptop) []interface{} { return one(float64(l.GetCpu().GetNumberCores())) }},
+	"cpu.threads":   {kindNumber, func(l *proto.Laptop) []interface{} { return one(float64(l.GetCpu().GetNumberThreads())) }},
+	"cpu.ghz":       {kindNumber, func(l *proto.Laptop) []interface{} { return one(l.GetCpu().GetMinGhz()) }},
+	"cpu.max_ghz":   {kindNumber, func(l *proto.Laptop) []interface{} { return one(l.GetCpu().GetMaxGhz()) }},
+	"ram":           {kindMemory, func(l *proto.Laptop) []interface{} { return one(float64(toBit(l.GetRam()))) }},
+	"gpu.brand":     {kindString, eachGPU(func(g *proto.GPU) interface{} { return g.GetBrand() })},
+	"gpu.name":      {kindString, eachGPU(func(g *proto.GPU) interface{} { return g.GetName() })},
+	"gpu.memory":    {kindMemory, eachGPU(func(g *proto.GPU) interface{} { return float64(toBit(g.GetMemory())) })},
+	"ssd":           {kindMemory, func(l *proto.Laptop) []interface{} { return one(float64(storageCapacity(l, proto.Storage_SSD))) }},
+	"hdd":           {kindMemory, func(l *proto.Laptop) []interface{} { return one(float64(storageCapacity(l, proto.Storage_HDD))) }},
+	"screen.size":   {kindNumber, func(l *proto.Laptop) []interface{} { return one(float64(l.GetScreen().GetSizeInch())) }},
+	"screen.width":  {kindNumber, func(l *proto.Laptop) []interface{} { return one(float64(l.GetScreen().GetResolution().GetWidth())) }},
+	"screen.height": {kindNumber, func(l *proto.Laptop) []interface{} { return one(float64(l.GetScreen().GetResolution().GetHeight())) }},
+	"screen.panel":  {kindString, func(l *proto.Laptop) []interface{} { return one(l.GetScreen().GetPanel().String()) }},
+	"screen.touch":  {kindBool, func(l *proto.Laptop) []interface{} { return one(l.GetScreen().GetMultitouch()) }},
+	"keyboard.layout": {kindString, func(l *proto.Laptop) []interface{} {
+		return one(l.GetKeyboard().GetLayout().String())
+	}},
+	"keyboard.backlit": {kindBool, func(l *proto.Laptop) []interface{} { return one(l.GetKeyboard().GetBacklit()) }},
+	"weight": {kindNumber, func(l *proto.Laptop) []interface{} {
+		kg, ok := weightKg(l)
+		if !ok {
+			return nil
+		}
+		return one(kg)
+	}},
+}
+
+type andQuery struct {
+	left, right Query
+}
+
+func (q *andQuery) Match(laptop *proto.Laptop) bool {
+	return q.left.Match(laptop) && q.right.Match(laptop)
+}
+
+type orQuery struct {
+	left, right Query
+}
+
+func (q *orQuery) Match(laptop *proto.Laptop) bool {
+	return q.left.Match(laptop) || q.right.Match(laptop)
+}
+
+type notQuery struct {
+	query Query
+}
+
+func (q *notQuery) Match(laptop *proto.Laptop) bool {
+	return !q.query.Match(laptop)
+}
+
+// compareQuery compares a field with one value, or with a list of values for
+// the "in" operator.
+type compareQuery struct {
+	field  queryField
+	op     string
+	values []interface{}
+}
+
+func (q *compareQuery) Match(laptop *proto.Laptop) bool {
+	for _, actual := range q.field.values(laptop) {
+		for _, expected := range q.values {
+			if compareQueryValue(actual, q.op, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func compareQueryValue(actual interface{}, op string, expected interface{}) bool {
+	switch actual := actual.(type) {
+	case string:
+		equal := strings.EqualFold(actual, expected.(string))
+		return equal == (op != "!=")
+	case bool:
+		equal := actual == expected.(bool)
+		return equal == (op != "!=")
+	case float64:
+		expected := expected.(float64)
+		switch op {
+		case "=", "in":
+			return actual == expected
+		case "!=":
+			return actual != expected
+		case "<":
+			return actual < expected
+		case "<=":
+			return actual <= expected
+		case ">":
+			return actual > expected
+		case ">=":
+			return actual >= expected
+		}
+	}
+	return false
+}
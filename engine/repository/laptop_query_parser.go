@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenWord
+	tokenNumber
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	typ  tokenType
+	text string
+	unit string
+	pos  int
+}
+
+// memoryUnits maps the suffix of a memory literal such as 16GB to its size
+// in bits.
+var memoryUnits = map[string]float64{
+	"B":  1 << 3,
+	"KB": 1 << 13,
+	"MB": 1 << 23,
+	"GB": 1 << 33,
+	"TB": 1 << 43,
+}
+
+// Limits of a laptop query, so a hostile query can neither exhaust the stack
+// of the parser nor build a huge expression.
+const (
+	maxQueryLength = 4096
+	maxQueryDepth  = 100
+)
+
+// ParseQuery parses a laptop query. The grammar is
+//
+//	expr       = and { "or" and }
+//	and        = not { "and" not }
+//	not        = "not" not | "(" expr ")" | comparison
+//	comparison = field op value | field [ "not" ] "in" "(" value { "," value } ")"
+//	op         = "=" | "!=" | "<" | "<=" | ">" | ">="
+//
+// Values are numbers, memory sizes like 512MB or 16GB, quoted strings, bare
+// words and true/false. Keywords and text comparisons are case-insensitive.
+// A query has at most maxQueryLength characters and nests "not" and
+// parentheses at most maxQueryDepth levels deep.
+func ParseQuery(text string) (Query, error) {
+	if utf8.RuneCountInString(text) > maxQueryLength {
+		return nil, &QueryError{Pos: maxQueryLength + 1, Msg: fmt.Sprintf("query is longer than %d characters", maxQueryLength)}
+	}
+
+	tokens, err := lexQuery(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	query, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.typ != tokenEOF {
+		return nil, p.errorf(next, "unexpected %q", next.text)
+	}
+
+	return query, nil
+}
+
+func lexQuery(text string) ([]token, error) {
+	var tokens []token
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{typ: tokenLeftParen, text: "(", pos: start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{typ: tokenRightParen, text: ")", pos: start + 1})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{typ: tokenComma, text: ",", pos: start + 1})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, &QueryError{Pos: start + 1, Msg: `expected "!="`}
+			}
+			if op == "==" {
+				op = "="
+			}
+			tokens = append(tokens, token{typ: tokenOperator, text: op, pos: start + 1})
+		case r == '"' || r == '\'':
+			i++
+			for i < len(runes) && runes[i] != r {
+				i++
+			}
+			if i == len(runes) {
+				return nil, &QueryError{Pos: start + 1, Msg: "unterminated string"}
+			}
+			tokens = append(tokens, token{typ: tokenString, text: string(runes[start+1 : i]), pos: start + 1})
+			i++
+		case unicode.IsDigit(r):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			end := i
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{typ: tokenNumber, text: string(runes[start:end]), unit: string(runes[end:i]), pos: start + 1})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || strings.ContainsRune("_.-", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{typ: tokenWord, text: string(runes[start:i]), pos: start + 1})
+		default:
+			return nil, &QueryError{Pos: start + 1, Msg: "unexpected character " + strconv.QuoteRune(r)}
+		}
+	}
+
+	return append(tokens, token{typ: tokenEOF, text: "end of query", pos: len(runes) + 1}), nil
+}
+
+type queryParser struct {
+	tokens []token
+	next   int
+	// depth counts the enclosing "not" and parentheses
+	depth int
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *queryParser) advance() token {
+	t := p.tokens[p.next]
+	if t.typ != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *queryParser) errorf(t token, format string, args ...interface{}) error {
+	return &QueryError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func isKeyword(t token, keyword string) bool {
+	return t.typ == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *queryParser) parseOr() (Query, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for isKeyword(p.peek(), "or") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orQuery{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (Query, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for isKeyword(p.peek(), "and") {
+		p.advance()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andQuery{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (Query, error) {
+	t := p.peek()
+
+	if isKeyword(t, "not") || t.typ == tokenLeftParen {
+		if p.depth == maxQueryDepth {
+			return nil, p.errorf(t, "query is nested deeper than %d levels", maxQueryDepth)
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+	}
+
+	if isKeyword(t, "not") {
+		p.advance()
+		query, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notQuery{query: query}, nil
+	}
+
+	if t.typ == tokenLeftParen {
+		p.advance()
+		query, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.advance(); closing.typ != tokenRightParen {
+			return nil, p.errorf(closing, `expected ")" but found %q`, closing.text)
+		}
+		return query, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (Query, error) {
+	name := p.advance()
+	if name.typ != tokenWord {
+		return nil, p.errorf(name, "expected a field name but found %q", name.text)
+	}
+
+	field, ok := queryFields[strings.ToLower(name.text)]
+	if !ok {
+		return nil, p.errorf(name, "unknown field %q", name.text)
+	}
+
+	op := p.advance()
+	negate := false
+	if isKeyword(op, "not") {
+		negate = true
+		op = p.advance()
+		if !isKeyword(op, "in") {
+			return nil, p.errorf(op, `expected "in" after "not" but found %q`, op.text)
+		}
+	}
+
+	var query Query
+	switch {
+	case isKeyword(op, "in"):
+		values, err := p.parseValueList(field)
+		if err != nil {
+			return nil, err
+		}
+		query = &compareQuery{field: field, op: "in", values: values}
+	case op.typ == tokenOperator:
+		if (field.kind == kindString || field.kind == kindBool) && op.text != "=" && op.text != "!=" {
+			return nil, p.errorf(op, "operator %s is not supported for %s field %q", op.text, field.kind, name.text)
+		}
+		value, err := p.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+		query = &compareQuery{field: field, op: op.text, values: []interface{}{value}}
+	default:
+		return nil, p.errorf(op, "expected an operator but found %q", op.text)
+	}
+
+	if negate {
+		return &notQuery{query: query}, nil
+	}
+	return query, nil
+}
+
+func (p *queryParser) parseValueList(field queryField) ([]interface{}, error) {
+	if open := p.advance(); open.typ != tokenLeftParen {
+		return nil, p.errorf(open, `expected "(" but found %q`, open.text)
+	}
+
+	var values []interface{}
+	for {
+		value, err := p.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		t := p.advance()
+		if t.typ == tokenRightParen {
+			return values, nil
+		}
+		if t.typ != tokenComma {
+			return nil, p.errorf(t, `expected "," or ")" but found %q`, t.text)
+		}
+	}
+}
+
+// parseValue reads a literal and converts it to the representation used by
+// the field.
+func (p *queryParser) parseValue(field queryField) (interface{}, error) {
+	t := p.advance()
+
+	switch field.kind {
+	case kindString:
+		if t.typ == tokenString || t.typ == tokenWord || (t.typ == tokenNumber && t.unit == "") {
+			return t.text + t.unit, nil
+		}
+	case kindBool:
+		if isKeyword(t, "true") {
+			return true, nil
+		}
+		if isKeyword(t, "false") {
+			return false, nil
+		}
+	case kindNumber:
+		if t.typ == tokenNumber && t.unit == "" {
+			return p.parseNumber(t)
+		}
+	case kindMemory:
+		if t.typ == tokenNumber {
+			unit, ok := memoryUnits[strings.ToUpper(t.unit)]
+			if !ok {
+				return nil, p.errorf(t, "memory size %q needs a unit: B, KB, MB, GB or TB", t.text+t.unit)
+			}
+			number, err := p.parseNumber(t)
+			if err != nil {
+				return nil, err
+			}
+			return number * unit, nil
+		}
+	}
+
+	if t.typ == tokenEOF {
+		return nil, p.errorf(t, "expected a %s value but found end of query", field.kind)
+	}
+	return nil, p.errorf(t, "expected a %s value but found %q", field.kind, t.text+t.unit)
+}
+
+func (p *queryParser) parseNumber(t token) (float64, error) {
+	number, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return 0, p.errorf(t, "invalid number %q", t.text)
+	}
+	return number, nil
+}
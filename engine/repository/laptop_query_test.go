@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	laptop := &proto.Laptop{
+		Brand: "Lenovo",
+		Name:  "Thinkpad P1",
+		Cpu:   &proto.CPU{Brand: "AMD", Name: "Ryzen 7 PRO 2700U", NumberCores: 8, MinGhz: 2.4},
+		Ram:   &proto.Memory{Value: 8, Unit: proto.Memory_GIGABYTE},
+		Gpus: []*proto.GPU{
+			{Brand: "AMD", Memory: &proto.Memory{Value: 2, Unit: proto.Memory_GIGABYTE}},
+			{Brand: "NVIDIA", Memory: &proto.Memory{Value: 6, Unit: proto.Memory_GIGABYTE}},
+		},
+		Storages: []*proto.Storage{
+			{Driver: proto.Storage_SSD, Memory: &proto.Memory{Value: 1, Unit: proto.Memory_TERABYTE}},
+		},
+		Screen:      &proto.Screen{SizeInch: 15.6, Panel: proto.Screen_OLED},
+		Keyboard:    &proto.Keyboard{Layout: proto.Keyboard_QWERTY, Backlit: true},
+		Weight:      &proto.Laptop_WeightKg{WeightKg: 1.8},
+		PriceUsd:    2400,
+		ReleaseYear: 2021,
+	}
+
+	testCases := []struct {
+		query string
+		match bool
+	}{
+		{`brand in (Dell, Lenovo) and (ram >= 16GB or gpu.memory >= 6GB)`, true},
+		{`brand in (Dell, Lenovo) and (ram >= 16GB or gpu.memory >= 8GB)`, false},
+		{`brand not in (dell, apple)`, true},
+		{`not brand = lenovo`, false},
+		{`name = "thinkpad p1"`, true},
+		{`cpu.name != 'Ryzen 7 PRO 2700U'`, false},
+		{`cpu.cores >= 8 AND cpu.ghz < 2.5`, true},
+		{`price <= 2000 or release_year == 2021`, true},
+		{`ssd >= 1024GB and ram = 8192MB`, true},
+		{`gpu.brand = nvidia and gpu.brand = amd`, true},
+		{`screen.panel = oled and keyboard.layout = QWERTY and keyboard.backlit = true`, true},
+		{`screen.touch = true`, false},
+		{`weight < 2 and screen.size > 15`, true},
+		{strings.Repeat("(", 100) + "price > 2000" + strings.Repeat(")", 100), true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+
+			query, err := ParseQuery(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.match, query.Match(laptop))
+		})
+	}
+}
+
+func TestParseQueryError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		query string
+		pos   int
+	}{
+		{`brand = `, 9},
+		{`colour = red`, 1},
+		{`ram >= 16`, 8},
+		{`price >= 16GB`, 10},
+		{`brand > Dell`, 7},
+		{`brand in (Dell, Lenovo`, 23},
+		{`(price < 1000`, 14},
+		{`price < 1000 brand = Dell`, 14},
+		{`name = "XPS`, 8},
+		{`price ! 1000`, 7},
+		{`brand not Dell`, 11},
+		{`price < 1000 and # 2`, 18},
+		{strings.Repeat("(", 4000), 101},
+		{strings.Repeat("not ", 100) + "(price < 1000)", 401},
+		{strings.Repeat("(", 4097), 4097},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.query, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseQuery(tc.query)
+			require.Error(t, err)
+
+			queryErr, ok := err.(*QueryError)
+			require.True(t, ok)
+			require.Equal(t, tc.pos, queryErr.Pos, queryErr.Error())
+		})
+	}
+}
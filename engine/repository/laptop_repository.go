@@ -1,33 +1,79 @@
 package repository
 
 import (
+	"container/heap"
 	"context"
 	"errors"
-	"fmt"
-	"github.com/jinzhu/copier"
 	"gitlab.com/iruldev/grpc-class/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 )
 
-var ErrAlreadyExists = errors.New("record already exists")
+var (
+	ErrAlreadyExists   = errors.New("record already exists")
+	ErrNotFound        = errors.New("record not found")
+	ErrVersionMismatch = errors.New("record version mismatch")
+)
 
+// LaptopRepository stores laptops as immutable snapshots. Save and Update
+// keep their own copy of the given laptop and Find returns a copy the caller
+// may modify. The laptops passed to the callbacks of Search and List and the
+// one returned by Update are shared snapshots and must not be modified.
 type LaptopRepository interface {
 	Save(laptop *proto.Laptop) error
 	Find(id string) (*proto.Laptop, error)
-	Search(ctx context.Context, filter *proto.Filter, found func(laptop *proto.Laptop) error) error
+	Update(laptop *proto.Laptop) (*proto.Laptop, error)
+	Delete(id string, version uint64) error
+	Search(ctx context.Context, filter *proto.Filter, options SearchOptions, found func(laptop *proto.Laptop) error) error
+	List(ctx context.Context, found func(laptop *proto.Laptop) error) error
+	Facets(ctx context.Context, filter *proto.Filter, options SearchOptions) (*Facets, error)
+}
+
+// SearchOptions narrows down, orders and limits the laptops returned by
+// Search. Laptops are always returned in a deterministic order, ties are
+// broken by laptop ID.
+type SearchOptions struct {
+	// Query must match in addition to the filter when it is not nil.
+	Query Query
+	// Text restricts the results to laptops whose brand, name, CPU or GPU
+	// contain every word of it. Without an explicit order the results are
+	// ranked by relevance.
+	Text  string
+	Order Order
+	// Limit caps the number of laptops, zero means no limit.
+	Limit int
+	// Offset skips the first laptops of the ordered results, Limit counts
+	// the laptops after them.
+	Offset int
+	// After skips the laptops that do not sort after these sort values, as
+	// returned by Order.Values, to continue from the end of a previous page.
+	After []interface{}
+	// Rating supplies average scores when Order has a rating key.
+	Rating func(laptopID string) float64
 }
 
 type LaptopRepositoryImpl struct {
-	mutex sync.RWMutex
-	data  map[string]*proto.Laptop
+	mutex   sync.RWMutex
+	data    map[string]*proto.Laptop
+	index   *textIndex
+	indexes *laptopIndexes
 }
 
 func NewLaptopRepository() LaptopRepository {
+	return newLaptopRepositoryImpl()
+}
+
+func newLaptopRepositoryImpl() *LaptopRepositoryImpl {
 	return &LaptopRepositoryImpl{
-		data: make(map[string]*proto.Laptop),
+		data:    make(map[string]*proto.Laptop),
+		index:   newTextIndex(),
+		indexes: newLaptopIndexes(),
 	}
 }
 
@@ -39,12 +85,13 @@ func (r *LaptopRepositoryImpl) Save(laptop *proto.Laptop) error {
 		return ErrAlreadyExists
 	}
 
-	other, err := deepCopy(laptop)
-	if err != nil {
-		return err
-	}
+	other := clone(laptop)
+	other.Version = 1
+	other.UpdatedAt = timestamppb.Now()
 
 	r.data[other.Id] = other
+	r.index.add(other)
+	r.indexes.insert(other)
 	return nil
 }
 
@@ -54,53 +101,310 @@ func (r *LaptopRepositoryImpl) Find(id string) (*proto.Laptop, error) {
 
 	laptop := r.data[id]
 	if laptop == nil {
-		return nil, errors.New("record not exists")
+		return nil, ErrNotFound
+	}
+
+	return clone(laptop), nil
+}
+
+// Update replaces the stored laptop. A non-zero laptop.Version must match the
+// stored version, otherwise ErrVersionMismatch is returned.
+func (r *LaptopRepositoryImpl) Update(laptop *proto.Laptop) (*proto.Laptop, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.data[laptop.Id]
+	if current == nil {
+		return nil, ErrNotFound
+	}
+
+	if laptop.Version != 0 && laptop.Version != current.Version {
+		return nil, ErrVersionMismatch
+	}
+
+	other := clone(laptop)
+	other.Version = current.Version + 1
+	other.UpdatedAt = timestamppb.Now()
+
+	r.data[other.Id] = other
+	r.index.add(other)
+	r.indexes.remove(current)
+	r.indexes.insert(other)
+	return other, nil
+}
+
+// Delete removes the laptop. A non-zero version must match the stored
+// version, otherwise ErrVersionMismatch is returned.
+func (r *LaptopRepositoryImpl) Delete(id string, version uint64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.data[id]
+	if current == nil {
+		return ErrNotFound
 	}
 
-	return deepCopy(laptop)
+	if version != 0 && version != current.Version {
+		return ErrVersionMismatch
+	}
+
+	delete(r.data, id)
+	r.index.remove(id)
+	r.indexes.remove(current)
+	return nil
 }
 
-func (r *LaptopRepositoryImpl) Search(ctx context.Context, filter *proto.Filter, found func(laptop *proto.Laptop) error) error {
+// snapshot returns the stored laptop, or nil if there is none.
+func (r *LaptopRepositoryImpl) snapshot(id string) *proto.Laptop {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	for _, laptop := range r.data {
-		if ctx.Err() == context.Canceled {
-			log.Print("request is canceled")
-			return status.Error(codes.Canceled, "request is canceled")
+	return r.data[id]
+}
+
+// put stores a laptop snapshot as it is, replacing any laptop with the same
+// ID. It restores laptops from persistent storage.
+func (r *LaptopRepositoryImpl) put(laptop *proto.Laptop) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if current := r.data[laptop.Id]; current != nil {
+		r.indexes.remove(current)
+	}
+
+	r.data[laptop.Id] = laptop
+	r.index.add(laptop)
+	r.indexes.insert(laptop)
+}
+
+// remove deletes a laptop regardless of its version.
+func (r *LaptopRepositoryImpl) remove(id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.data[id]
+	if current == nil {
+		return
+	}
+
+	delete(r.data, id)
+	r.index.remove(id)
+	r.indexes.remove(current)
+}
+
+func (r *LaptopRepositoryImpl) Search(ctx context.Context, filter *proto.Filter, options SearchOptions, found func(laptop *proto.Laptop) error) error {
+	laptops, err := r.search(ctx, filter, options)
+	if err != nil {
+		return err
+	}
+
+	for _, laptop := range laptops {
+		err := found(laptop)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// search collects the qualified laptops under the read lock and returns their
+// snapshots in order. They are ordered after the lock is released, since
+// options.Rating may take the locks of a rating repository whose callers take
+// this lock in turn.
+func (r *LaptopRepositoryImpl) search(ctx context.Context, filter *proto.Filter, options SearchOptions) ([]*proto.Laptop, error) {
+	laptops, relevance, err := r.collect(ctx, filter, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectLaptops(options, relevance, func(found func(laptop *proto.Laptop)) error {
+		for _, laptop := range laptops {
+			found(laptop)
+		}
+		return nil
+	})
+}
+
+func (r *LaptopRepositoryImpl) collect(ctx context.Context, filter *proto.Filter, options SearchOptions) ([]*proto.Laptop, map[string]float64, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var laptops []*proto.Laptop
+	relevance := r.index.search(options.Text)
+	err := r.scan(ctx, filter, options.Query, relevance, func(laptop *proto.Laptop) {
+		laptops = append(laptops, laptop)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return laptops, relevance, nil
+}
+
+// selectLaptops orders the laptops that each passes to found and returns them
+// in order. Relevance holds the text search scores, without an explicit order
+// a non-nil relevance ranks the laptops by it. With a limit only the best
+// laptops, including the skipped ones, are kept on a bounded heap instead of
+// sorting every match.
+func selectLaptops(options SearchOptions, relevance map[string]float64, each func(found func(laptop *proto.Laptop)) error) ([]*proto.Laptop, error) {
+	if relevance != nil && len(options.Order) == 0 {
+		options.Order = Order{{Field: OrderByRelevance, Descending: true}}
+	}
+
+	score := func(laptopID string) float64 {
+		return relevance[laptopID]
+	}
+
+	limit := options.Limit
+	if limit > 0 {
+		limit += options.Offset
+	}
+
+	selected := &laptopHeap{order: options.Order}
+	err := each(func(laptop *proto.Laptop) {
+		item := orderedLaptop{laptop: laptop, values: options.Order.Values(laptop, options.Rating, score)}
+		if options.After != nil && options.Order.Compare(item.values, options.After) <= 0 {
+			return
+		}
+
+		switch {
+		case limit <= 0:
+			selected.items = append(selected.items, item)
+		case selected.Len() < limit:
+			heap.Push(selected, item)
+		case options.Order.Compare(item.values, selected.items[0].values) < 0:
+			selected.items[0] = item
+			heap.Fix(selected, 0)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(selected.items, func(i, j int) bool {
+		return options.Order.Compare(selected.items[i].values, selected.items[j].values) < 0
+	})
+	if options.Offset < len(selected.items) {
+		selected.items = selected.items[options.Offset:]
+	} else {
+		selected.items = nil
+	}
+
+	laptops := make([]*proto.Laptop, len(selected.items))
+	for i, item := range selected.items {
+		laptops[i] = item.laptop
+	}
+
+	return laptops, nil
+}
+
+// scan calls found with every stored laptop that satisfies the filter and the
+// query. Only the laptops matched by a text search, given as a non-nil
+// relevance map, or by the most selective indexed filter field are checked.
+// The caller must hold the read lock.
+func (r *LaptopRepositoryImpl) scan(ctx context.Context, filter *proto.Filter, query Query, relevance map[string]float64, found func(laptop *proto.Laptop)) error {
+	check := func(laptop *proto.Laptop) error {
+		if err := contextError(ctx); err != nil {
+			return err
+		}
+
+		if IsQualified(filter, laptop) && (query == nil || query.Match(laptop)) {
+			found(laptop)
 		}
+		return nil
+	}
 
-		if ctx.Err() == context.DeadlineExceeded {
-			log.Print("deadline is exceeded")
-			return status.Error(codes.DeadlineExceeded, "deadline is exceeded")
+	ids, indexed := r.indexes.candidates(filter)
+	if relevance != nil && (!indexed || len(relevance) < len(ids)) {
+		ids, indexed = make([]string, 0, len(relevance)), true
+		for id := range relevance {
+			ids = append(ids, id)
 		}
+	}
 
-		if isQualified(filter, laptop) {
-			other, err := deepCopy(laptop)
-			if err != nil {
+	if !indexed {
+		for _, laptop := range r.data {
+			if err := check(laptop); err != nil {
 				return err
 			}
+		}
+		return nil
+	}
 
-			err = found(other)
-			if err != nil {
-				return err
+	for _, id := range ids {
+		if relevance != nil {
+			if _, ok := relevance[id]; !ok {
+				continue
 			}
 		}
+		if err := check(r.data[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *LaptopRepositoryImpl) Facets(ctx context.Context, filter *proto.Filter, options SearchOptions) (*Facets, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	facets := newFacets()
+	err := r.scan(ctx, filter, options.Query, r.index.search(options.Text), facets.add)
+	if err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+func (r *LaptopRepositoryImpl) List(ctx context.Context, found func(laptop *proto.Laptop) error) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, laptop := range r.data {
+		if err := contextError(ctx); err != nil {
+			return err
+		}
+
+		err := found(laptop)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func isQualified(filter *proto.Filter, laptop *proto.Laptop) bool {
-	if laptop.GetPriceUsd() > filter.GetMaxPriceUsd() {
+func contextError(ctx context.Context) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		log.Print("request is canceled")
+		return status.Error(codes.Canceled, "request is canceled")
+	case context.DeadlineExceeded:
+		log.Print("deadline is exceeded")
+		return status.Error(codes.DeadlineExceeded, "deadline is exceeded")
+	default:
+		return nil
+	}
+}
+
+// IsQualified reports whether the laptop satisfies every constraint set in
+// the filter. Fields that are not set, and a nil filter, constrain nothing.
+func IsQualified(filter *proto.Filter, laptop *proto.Laptop) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.MaxPriceUsd != nil && laptop.GetPriceUsd() > filter.GetMaxPriceUsd() {
 		return false
 	}
 
-	if laptop.GetCpu().GetNumberCores() < filter.GetMinCpuCores() {
+	if filter.MinCpuCores != nil && laptop.GetCpu().GetNumberCores() < filter.GetMinCpuCores() {
 		return false
 	}
 
-	if laptop.GetCpu().GetMinGhz() < filter.GetMinCpuGhz() {
+	if filter.MinCpuGhz != nil && laptop.GetCpu().GetMinGhz() < filter.GetMinCpuGhz() {
 		return false
 	}
 
@@ -108,7 +412,147 @@ func isQualified(filter *proto.Filter, laptop *proto.Laptop) bool {
 		return false
 	}
 
-	return true
+	if filter.MinPriceUsd != nil && laptop.GetPriceUsd() < filter.GetMinPriceUsd() {
+		return false
+	}
+
+	if !containsFold(filter.GetBrands(), laptop.GetBrand()) || !containsFold(filter.GetNames(), laptop.GetName()) {
+		return false
+	}
+
+	if !isGPUQualified(filter, laptop.GetGpus()) {
+		return false
+	}
+
+	if storageCapacity(laptop, proto.Storage_SSD) < toBit(filter.GetMinSsdCapacity()) ||
+		storageCapacity(laptop, proto.Storage_HDD) < toBit(filter.GetMinHddCapacity()) {
+		return false
+	}
+
+	return isScreenQualified(filter, laptop.GetScreen()) &&
+		isKeyboardQualified(filter, laptop.GetKeyboard()) &&
+		isReleaseYearQualified(filter, laptop.GetReleaseYear()) &&
+		isWeightQualified(filter, laptop)
+}
+
+// isGPUQualified reports whether one of the GPUs matches both the brand and
+// the memory constraints of the filter.
+func isGPUQualified(filter *proto.Filter, gpus []*proto.GPU) bool {
+	if len(filter.GetGpuBrands()) == 0 && filter.GetMinGpuMemory() == nil {
+		return true
+	}
+
+	for _, gpu := range gpus {
+		if containsFold(filter.GetGpuBrands(), gpu.GetBrand()) && toBit(gpu.GetMemory()) >= toBit(filter.GetMinGpuMemory()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isScreenQualified(filter *proto.Filter, screen *proto.Screen) bool {
+	if filter.MinScreenInch != nil && screen.GetSizeInch() < filter.GetMinScreenInch() {
+		return false
+	}
+
+	if filter.MaxScreenInch != nil && screen.GetSizeInch() > filter.GetMaxScreenInch() {
+		return false
+	}
+
+	resolution := screen.GetResolution()
+	if resolution.GetWidth() < filter.GetMinResolution().GetWidth() || resolution.GetHeight() < filter.GetMinResolution().GetHeight() {
+		return false
+	}
+
+	if len(filter.GetPanels()) > 0 && !containsEnum(filter.GetPanels(), screen.GetPanel()) {
+		return false
+	}
+
+	return filter.Multitouch == nil || screen.GetMultitouch() == filter.GetMultitouch()
+}
+
+func isKeyboardQualified(filter *proto.Filter, keyboard *proto.Keyboard) bool {
+	if len(filter.GetKeyboardLayouts()) > 0 && !containsEnum(filter.GetKeyboardLayouts(), keyboard.GetLayout()) {
+		return false
+	}
+
+	return filter.KeyboardBacklit == nil || keyboard.GetBacklit() == filter.GetKeyboardBacklit()
+}
+
+func isReleaseYearQualified(filter *proto.Filter, year uint32) bool {
+	if filter.MinReleaseYear != nil && year < filter.GetMinReleaseYear() {
+		return false
+	}
+
+	return filter.MaxReleaseYear == nil || year <= filter.GetMaxReleaseYear()
+}
+
+// isWeightQualified compares weights in kilograms. A laptop without a weight
+// never satisfies a weight constraint.
+func isWeightQualified(filter *proto.Filter, laptop *proto.Laptop) bool {
+	var maxKg float64
+	switch weight := filter.GetMaxWeight().(type) {
+	case *proto.Filter_MaxWeightKg:
+		maxKg = weight.MaxWeightKg
+	case *proto.Filter_MaxWeightLb:
+		maxKg = weight.MaxWeightLb * kgPerLb
+	default:
+		return true
+	}
+
+	kg, ok := weightKg(laptop)
+	return ok && kg <= maxKg
+}
+
+const kgPerLb = 0.45359237
+
+// weightKg returns the weight of the laptop in kilograms, if it is known.
+func weightKg(laptop *proto.Laptop) (float64, bool) {
+	switch weight := laptop.GetWeight().(type) {
+	case *proto.Laptop_WeightKg:
+		return weight.WeightKg, true
+	case *proto.Laptop_WeightLb:
+		return weight.WeightLb * kgPerLb, true
+	default:
+		return 0, false
+	}
+}
+
+// storageCapacity returns the total capacity in bits of the storages that use
+// the given driver.
+func storageCapacity(laptop *proto.Laptop, driver proto.Storage_Driver) uint64 {
+	var total uint64
+	for _, storage := range laptop.GetStorages() {
+		if storage.GetDriver() == driver {
+			total += toBit(storage.GetMemory())
+		}
+	}
+	return total
+}
+
+// containsFold reports whether value case-insensitively equals one of the
+// values in set. An empty set contains every value.
+func containsFold(set []string, value string) bool {
+	if len(set) == 0 {
+		return true
+	}
+
+	for _, item := range set {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEnum[T comparable](set []T, value T) bool {
+	for _, item := range set {
+		if item == value {
+			return true
+		}
+	}
+	return false
 }
 
 func toBit(memory *proto.Memory) uint64 {
@@ -132,12 +576,6 @@ func toBit(memory *proto.Memory) uint64 {
 	}
 }
 
-func deepCopy(laptop *proto.Laptop) (*proto.Laptop, error) {
-	// deep copy
-	other := &proto.Laptop{}
-	err := copier.Copy(other, laptop)
-	if err != nil {
-		return nil, fmt.Errorf("cannot copy laptop data: %w", err)
-	}
-	return other, nil
+func clone(laptop *proto.Laptop) *proto.Laptop {
+	return protobuf.Clone(laptop).(*proto.Laptop)
 }
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"gitlab.com/iruldev/grpc-class/sample"
+	"testing"
+)
+
+func TestIsQualified(t *testing.T) {
+	t.Parallel()
+
+	laptop := &proto.Laptop{
+		Brand: "Lenovo",
+		Name:  "Thinkpad P1",
+		Cpu:   &proto.CPU{NumberCores: 8, MinGhz: 2.4},
+		Ram:   &proto.Memory{Value: 16, Unit: proto.Memory_GIGABYTE},
+		Gpus: []*proto.GPU{
+			{Brand: "Intel", Memory: &proto.Memory{Value: 512, Unit: proto.Memory_MEGABYTE}},
+			{Brand: "NVIDIA", Memory: &proto.Memory{Value: 6, Unit: proto.Memory_GIGABYTE}},
+		},
+		Storages: []*proto.Storage{
+			{Driver: proto.Storage_SSD, Memory: &proto.Memory{Value: 512, Unit: proto.Memory_GIGABYTE}},
+			{Driver: proto.Storage_SSD, Memory: &proto.Memory{Value: 512, Unit: proto.Memory_GIGABYTE}},
+			{Driver: proto.Storage_HDD, Memory: &proto.Memory{Value: 2, Unit: proto.Memory_TERABYTE}},
+		},
+		Screen: &proto.Screen{
+			SizeInch:   15.6,
+			Resolution: &proto.Screen_Resolution{Width: 3840, Height: 2160},
+			Panel:      proto.Screen_OLED,
+			Multitouch: true,
+		},
+		Keyboard:    &proto.Keyboard{Layout: proto.Keyboard_QWERTY, Backlit: true},
+		Weight:      &proto.Laptop_WeightLb{WeightLb: 4},
+		PriceUsd:    2400,
+		ReleaseYear: 2021,
+	}
+
+	gigabytes := func(value uint64) *proto.Memory {
+		return &proto.Memory{Value: value, Unit: proto.Memory_GIGABYTE}
+	}
+	float32Ptr := func(value float32) *float32 { return &value }
+	uint32Ptr := func(value uint32) *uint32 { return &value }
+	float64Ptr := func(value float64) *float64 { return &value }
+	boolPtr := func(value bool) *bool { return &value }
+
+	testCases := []struct {
+		name      string
+		filter    *proto.Filter
+		qualified bool
+	}{
+		{"nil_filter", nil, true},
+		{"empty_filter", &proto.Filter{}, true},
+		{"max_price_match", &proto.Filter{MaxPriceUsd: float64Ptr(2400)}, true},
+		{"max_price_zero", &proto.Filter{MaxPriceUsd: float64Ptr(0)}, false},
+		{"min_cpu_cores_match", &proto.Filter{MinCpuCores: uint32Ptr(8)}, true},
+		{"min_cpu_cores_mismatch", &proto.Filter{MinCpuCores: uint32Ptr(12)}, false},
+		{"min_cpu_ghz_match", &proto.Filter{MinCpuGhz: float64Ptr(2.4)}, true},
+		{"min_cpu_ghz_mismatch", &proto.Filter{MinCpuGhz: float64Ptr(3)}, false},
+		{"min_ram_match", &proto.Filter{MinRam: gigabytes(16)}, true},
+		{"min_ram_mismatch", &proto.Filter{MinRam: gigabytes(32)}, false},
+		{"brands_match", &proto.Filter{Brands: []string{"dell", "lenovo"}}, true},
+		{"brands_mismatch", &proto.Filter{Brands: []string{"Dell"}}, false},
+		{"names_match", &proto.Filter{Names: []string{"THINKPAD P1"}}, true},
+		{"names_mismatch", &proto.Filter{Names: []string{"Thinkpad X1"}}, false},
+		{"min_price_match", &proto.Filter{MinPriceUsd: float64Ptr(2400)}, true},
+		{"min_price_mismatch", &proto.Filter{MinPriceUsd: float64Ptr(2500)}, false},
+		{"gpu_brand_match", &proto.Filter{GpuBrands: []string{"nvidia"}}, true},
+		{"gpu_brand_mismatch", &proto.Filter{GpuBrands: []string{"AMD"}}, false},
+		{"gpu_memory_match", &proto.Filter{MinGpuMemory: gigabytes(6)}, true},
+		{"gpu_memory_mismatch", &proto.Filter{MinGpuMemory: gigabytes(8)}, false},
+		{"gpu_brand_and_memory_on_different_gpus", &proto.Filter{GpuBrands: []string{"Intel"}, MinGpuMemory: gigabytes(6)}, false},
+		{"ssd_total_match", &proto.Filter{MinSsdCapacity: gigabytes(1024)}, true},
+		{"ssd_total_mismatch", &proto.Filter{MinSsdCapacity: gigabytes(1025)}, false},
+		{"hdd_total_match", &proto.Filter{MinHddCapacity: &proto.Memory{Value: 2, Unit: proto.Memory_TERABYTE}}, true},
+		{"hdd_total_mismatch", &proto.Filter{MinHddCapacity: &proto.Memory{Value: 3, Unit: proto.Memory_TERABYTE}}, false},
+		{"screen_range_match", &proto.Filter{MinScreenInch: float32Ptr(15), MaxScreenInch: float32Ptr(16)}, true},
+		{"screen_too_small", &proto.Filter{MinScreenInch: float32Ptr(16)}, false},
+		{"screen_too_large", &proto.Filter{MaxScreenInch: float32Ptr(14)}, false},
+		{"resolution_match", &proto.Filter{MinResolution: &proto.Screen_Resolution{Width: 1920, Height: 1080}}, true},
+		{"resolution_mismatch", &proto.Filter{MinResolution: &proto.Screen_Resolution{Width: 5120, Height: 1080}}, false},
+		{"panel_match", &proto.Filter{Panels: []proto.Screen_Panel{proto.Screen_IPS, proto.Screen_OLED}}, true},
+		{"panel_mismatch", &proto.Filter{Panels: []proto.Screen_Panel{proto.Screen_IPS}}, false},
+		{"multitouch_match", &proto.Filter{Multitouch: boolPtr(true)}, true},
+		{"multitouch_mismatch", &proto.Filter{Multitouch: boolPtr(false)}, false},
+		{"keyboard_layout_match", &proto.Filter{KeyboardLayouts: []proto.Keyboard_Layout{proto.Keyboard_QWERTY}}, true},
+		{"keyboard_layout_mismatch", &proto.Filter{KeyboardLayouts: []proto.Keyboard_Layout{proto.Keyboard_AZERTY}}, false},
+		{"keyboard_backlit_match", &proto.Filter{KeyboardBacklit: boolPtr(true)}, true},
+		{"keyboard_backlit_mismatch", &proto.Filter{KeyboardBacklit: boolPtr(false)}, false},
+		{"release_year_match", &proto.Filter{MinReleaseYear: uint32Ptr(2020), MaxReleaseYear: uint32Ptr(2021)}, true},
+		{"release_year_too_old", &proto.Filter{MinReleaseYear: uint32Ptr(2022)}, false},
+		{"release_year_too_new", &proto.Filter{MaxReleaseYear: uint32Ptr(2020)}, false},
+		{"weight_kg_match", &proto.Filter{MaxWeight: &proto.Filter_MaxWeightKg{MaxWeightKg: 1.9}}, true},
+		{"weight_kg_mismatch", &proto.Filter{MaxWeight: &proto.Filter_MaxWeightKg{MaxWeightKg: 1.8}}, false},
+		{"weight_lb_match", &proto.Filter{MaxWeight: &proto.Filter_MaxWeightLb{MaxWeightLb: 4}}, true},
+		{"weight_lb_mismatch", &proto.Filter{MaxWeight: &proto.Filter_MaxWeightLb{MaxWeightLb: 3.9}}, false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.qualified, IsQualified(tc.filter, laptop))
+		})
+	}
+}
+
+func TestLaptopRepositorySearchIndexed(t *testing.T) {
+	t.Parallel()
+
+	repo := NewLaptopRepository()
+	laptops := make(map[string]*proto.Laptop)
+	for i := 0; i < 3000; i++ {
+		laptop := sample.NewLaptop()
+		require.NoError(t, repo.Save(laptop))
+		laptops[laptop.Id] = laptop
+	}
+
+	// move some laptops around in the indexes and drop others
+	i := 0
+	for id, laptop := range laptops {
+		switch i % 5 {
+		case 0:
+			laptop.PriceUsd += 500
+			laptop.ReleaseYear--
+			_, err := repo.Update(laptop)
+			require.NoError(t, err)
+		case 1:
+			require.NoError(t, repo.Delete(id, 0))
+			delete(laptops, id)
+		}
+		i++
+	}
+
+	minPrice, maxPrice := 1800.0, 2600.0
+	minCores, minGhz := uint32(4), 2.5
+	minYear, maxYear := uint32(2017), uint32(2019)
+	filters := []*proto.Filter{
+		{MinPriceUsd: &minPrice, MaxPriceUsd: &maxPrice},
+		{MinCpuCores: &minCores, MinCpuGhz: &minGhz},
+		{MinRam: &proto.Memory{Value: 32, Unit: proto.Memory_GIGABYTE}, MinReleaseYear: &minYear},
+		{MaxReleaseYear: &maxYear, MaxPriceUsd: &maxPrice, Brands: []string{"Dell"}},
+	}
+
+	for _, filter := range filters {
+		var expected []string
+		for id, laptop := range laptops {
+			if IsQualified(filter, laptop) {
+				expected = append(expected, id)
+			}
+		}
+
+		var actual []string
+		err := repo.Search(context.Background(), filter, SearchOptions{}, func(laptop *proto.Laptop) error {
+			actual = append(actual, laptop.GetId())
+			return nil
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, expected, actual, filter.String())
+	}
+}
+
+func TestLaptopRepositorySnapshots(t *testing.T) {
+	t.Parallel()
+
+	repo := NewLaptopRepository()
+	laptop := sample.NewLaptop()
+	laptop.Weight = &proto.Laptop_WeightLb{WeightLb: 4}
+	require.NoError(t, repo.Save(laptop))
+
+	// changes to the saved laptop do not reach the stored snapshot
+	laptop.Brand = "changed"
+	laptop.GetCpu().NumberCores = 64
+
+	found, err := repo.Find(laptop.GetId())
+	require.NoError(t, err)
+	require.NotEqual(t, "changed", found.GetBrand())
+	require.NotEqual(t, uint32(64), found.GetCpu().GetNumberCores())
+	require.Equal(t, 4.0, found.GetWeightLb())
+	require.NotNil(t, found.GetUpdatedAt())
+
+	// neither do changes to a found laptop
+	found.GetGpus()[0].Brand = "changed"
+	found.Weight = &proto.Laptop_WeightKg{WeightKg: 1}
+
+	other, err := repo.Find(laptop.GetId())
+	require.NoError(t, err)
+	require.NotSame(t, found, other)
+	require.NotEqual(t, "changed", other.GetGpus()[0].GetBrand())
+	require.Equal(t, 4.0, other.GetWeightLb())
+
+	// an update stores a new snapshot and leaves the previous one intact
+	updated, err := repo.Update(found)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), updated.GetVersion())
+	require.Equal(t, "changed", updated.GetGpus()[0].GetBrand())
+	require.Equal(t, uint64(1), other.GetVersion())
+
+	found.Brand = "after update"
+	err = repo.List(context.Background(), func(listed *proto.Laptop) error {
+		require.Same(t, updated, listed)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// benchmarkRepository is shared by the benchmarks, filling it dominates the
+// time of a single benchmark run.
+var benchmarkRepository LaptopRepository
+
+func newBenchmarkRepository(b *testing.B) LaptopRepository {
+	if benchmarkRepository == nil {
+		benchmarkRepository = NewLaptopRepository()
+		for i := 0; i < 100_000; i++ {
+			require.NoError(b, benchmarkRepository.Save(sample.NewLaptop()))
+		}
+	}
+	b.ResetTimer()
+	return benchmarkRepository
+}
+
+// The indexed cases narrow the candidates with the price index, while the
+// scan cases express the same constraint as a query, which has to be
+// evaluated against every laptop.
+func BenchmarkSearch(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	maxPrice := 1505.0
+	filter := &proto.Filter{MaxPriceUsd: &maxPrice}
+	query, err := ParseQuery("price <= 1505")
+	require.NoError(b, err)
+
+	count := 0
+	found := func(laptop *proto.Laptop) error {
+		count++
+		return nil
+	}
+
+	b.Run("indexed", func(b *testing.B) {
+		count = 0
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, repo.Search(context.Background(), filter, SearchOptions{}, found))
+		}
+		b.ReportMetric(float64(count)/b.Elapsed().Seconds(), "laptops/s")
+	})
+
+	b.Run("scan", func(b *testing.B) {
+		count = 0
+		for i := 0; i < b.N; i++ {
+			require.NoError(b, repo.Search(context.Background(), nil, SearchOptions{Query: query}, found))
+		}
+		b.ReportMetric(float64(count)/b.Elapsed().Seconds(), "laptops/s")
+	})
+}
+
+func BenchmarkFacets(b *testing.B) {
+	repo := newBenchmarkRepository(b)
+	maxPrice := 1505.0
+	filter := &proto.Filter{MaxPriceUsd: &maxPrice}
+	query, err := ParseQuery("price <= 1505")
+	require.NoError(b, err)
+
+	b.Run("indexed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := repo.Facets(context.Background(), filter, SearchOptions{})
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := repo.Facets(context.Background(), nil, SearchOptions{Query: query})
+			require.NoError(b, err)
+		}
+	})
+}
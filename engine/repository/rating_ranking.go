@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RatingPrior ranks the laptops by a Bayesian average, which counts Weight
+// ratings of Mean in addition to the scores of the users. A laptop with few
+// ratings stays close to the mean while the scores of a laptop with many
+// ratings outweigh it.
+type RatingPrior struct {
+	// Mean is the score expected from a laptop before its ratings.
+	Mean float64
+	// Weight is the number of ratings the mean counts as, zero ranks the
+	// laptops by their plain average.
+	Weight float64
+}
+
+// DefaultRatingPrior expects the middle of the default rating scale.
+var DefaultRatingPrior = RatingPrior{Mean: 5.5, Weight: 5}
+
+// Validate checks that the prior gives every rating a finite score.
+func (p RatingPrior) Validate() error {
+	switch {
+	case math.IsNaN(p.Mean) || math.IsInf(p.Mean, 0):
+		return fmt.Errorf("rating prior mean must be finite")
+	case math.IsNaN(p.Weight) || math.IsInf(p.Weight, 0) || p.Weight < 0:
+		return fmt.Errorf("rating prior weight must be finite and not negative")
+	}
+	return nil
+}
+
+// BayesianAverage returns the average score with the prior counted in.
+func (r *Rating) BayesianAverage(prior RatingPrior) float64 {
+	if float64(r.Count)+prior.Weight == 0 {
+		return prior.Mean
+	}
+	return (prior.Weight*prior.Mean + r.Sum) / (prior.Weight + float64(r.Count))
+}
+
+// RankedRating is the rating of a laptop returned by TopRated.
+type RankedRating struct {
+	LaptopID string
+	// Score is the Bayesian average the laptop is ranked by.
+	Score  float64
+	Rating *Rating
+}
+
+// ratingRanking keeps the laptops in decreasing order of score, ties are
+// broken by laptop ID. Changing the score of a laptop moves it with a binary
+// search, so the ranking is never sorted as a whole.
+type ratingRanking struct {
+	entries []rankingEntry
+	scores  map[string]float64
+}
+
+type rankingEntry struct {
+	laptopID string
+	score    float64
+}
+
+func newRatingRanking() *ratingRanking {
+	return &ratingRanking{scores: make(map[string]float64)}
+}
+
+// set moves the laptop to the position of its new score.
+func (r *ratingRanking) set(laptopID string, score float64) {
+	r.remove(laptopID)
+
+	i := r.search(laptopID, score)
+	r.entries = append(r.entries, rankingEntry{})
+	copy(r.entries[i+1:], r.entries[i:])
+	r.entries[i] = rankingEntry{laptopID: laptopID, score: score}
+	r.scores[laptopID] = score
+}
+
+func (r *ratingRanking) remove(laptopID string) {
+	score, ok := r.scores[laptopID]
+	if !ok {
+		return
+	}
+
+	i := r.search(laptopID, score)
+	r.entries = append(r.entries[:i], r.entries[i+1:]...)
+	delete(r.scores, laptopID)
+}
+
+// search returns the position of a laptop with the score.
+func (r *ratingRanking) search(laptopID string, score float64) int {
+	return sort.Search(len(r.entries), func(i int) bool {
+		entry := r.entries[i]
+		return entry.score < score || (entry.score == score && entry.laptopID >= laptopID)
+	})
+}
+
+// page returns a copy of up to n entries that come after the last one, or
+// the first n entries if last is nil.
+func (r *ratingRanking) page(last *rankingEntry, n int) []rankingEntry {
+	start := 0
+	if last != nil {
+		start = sort.Search(len(r.entries), func(i int) bool {
+			entry := r.entries[i]
+			return entry.score < last.score || (entry.score == last.score && entry.laptopID > last.laptopID)
+		})
+	}
+
+	end := start + n
+	if end > len(r.entries) {
+		end = len(r.entries)
+	}
+	return append([]rankingEntry(nil), r.entries[start:end]...)
+}
@@ -1,40 +1,320 @@
 package repository
 
-import "sync"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
 
 type Rating struct {
 	Count uint32
 	Sum   float64
+	Min   float64
+	Max   float64
+	// Histogram counts the scores by their integer part, a score of 7.5 is
+	// counted in Histogram[7].
+	Histogram map[int]uint32
 }
 
+// Average returns the average score, or zero if there is no score.
+func (r *Rating) Average() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return r.Sum / float64(r.Count)
+}
+
+// add counts one more score.
+func (r *Rating) add(score float64) {
+	if r.Count == 0 || score < r.Min {
+		r.Min = score
+	}
+	if r.Count == 0 || score > r.Max {
+		r.Max = score
+	}
+	r.Count++
+	r.Sum += score
+
+	if r.Histogram == nil {
+		r.Histogram = make(map[int]uint32)
+	}
+	r.Histogram[scoreBucket(score)]++
+}
+
+// remove uncounts a score, Min and Max must be reset afterwards if the score
+// was one of them.
+func (r *Rating) remove(score float64) {
+	r.Count--
+	r.Sum -= score
+
+	bucket := scoreBucket(score)
+	r.Histogram[bucket]--
+	if r.Histogram[bucket] == 0 {
+		delete(r.Histogram, bucket)
+	}
+}
+
+// resetBounds recomputes Min and Max from all the scores of the laptop.
+func (r *Rating) resetBounds(scores map[string]float64) {
+	first := true
+	for _, score := range scores {
+		if first || score < r.Min {
+			r.Min = score
+		}
+		if first || score > r.Max {
+			r.Max = score
+		}
+		first = false
+	}
+}
+
+func (r *Rating) clone() *Rating {
+	other := *r
+	if r.Histogram != nil {
+		other.Histogram = make(map[int]uint32, len(r.Histogram))
+		for bucket, count := range r.Histogram {
+			other.Histogram[bucket] = count
+		}
+	}
+	return &other
+}
+
+// checkScore rejects the scores a rating cannot count, such as NaN.
+func checkScore(score float64) error {
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return fmt.Errorf("score %v is not a finite number", score)
+	}
+	return nil
+}
+
+// scoreBucket returns the histogram bucket of a score.
+func scoreBucket(score float64) int {
+	return int(math.Floor(score))
+}
+
+// RatingRepository keeps one score per user and laptop, and the rating of
+// every laptop computed from the scores of its users.
 type RatingRepository interface {
-	Add(laptopID string, score float64) (*Rating, error)
+	// Rate sets the score of a user for a laptop, replacing the previous
+	// score of the user, and returns the new rating of the laptop. The score
+	// must be a finite number.
+	Rate(laptopID, username string, score float64) (*Rating, error)
+	// Retract removes the score of a user for a laptop and returns the new
+	// rating of the laptop, or ErrNotFound if the user has not rated it.
+	Retract(laptopID, username string) (*Rating, error)
+	// Find returns the rating of a laptop or ErrNotFound if it has no score.
+	Find(laptopID string) (*Rating, error)
+	// FindAll returns the ratings of the laptops that have a score, keyed by
+	// laptop ID.
+	FindAll(laptopIDs []string) (map[string]*Rating, error)
+	// TopRated returns the ratings of up to limit laptops accepted by accept,
+	// in decreasing order of their Bayesian average. Zero limit means no
+	// limit.
+	TopRated(limit int, accept func(laptopID string) (bool, error)) ([]*RankedRating, error)
+	// SetPrior ranks the laptops with the prior, DefaultRatingPrior until
+	// it is set.
+	SetPrior(prior RatingPrior) error
 }
 
 type RatingRepositoryImpl struct {
 	mutex  sync.RWMutex
 	rating map[string]*Rating
+	// scores are keyed by laptop ID and then by username
+	scores  map[string]map[string]float64
+	prior   RatingPrior
+	ranking *ratingRanking
 }
 
 func NewRatingRepository() RatingRepository {
-	return &RatingRepositoryImpl{rating: make(map[string]*Rating)}
+	return newRatingRepositoryImpl()
+}
+
+func newRatingRepositoryImpl() *RatingRepositoryImpl {
+	return &RatingRepositoryImpl{
+		rating:  make(map[string]*Rating),
+		scores:  make(map[string]map[string]float64),
+		prior:   DefaultRatingPrior,
+		ranking: newRatingRanking(),
+	}
+}
+
+func (r *RatingRepositoryImpl) Rate(laptopID, username string, score float64) (*Rating, error) {
+	err := checkScore(score)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	scores := r.scores[laptopID]
+	if scores == nil {
+		scores = make(map[string]float64)
+		r.scores[laptopID] = scores
+	}
+
+	rating := r.rating[laptopID]
+	if rating == nil {
+		rating = &Rating{}
+		r.rating[laptopID] = rating
+	}
+
+	previous, replaced := scores[username]
+	if replaced {
+		rating.remove(previous)
+	}
+
+	scores[username] = score
+	rating.add(score)
+	if replaced && (previous == rating.Min || previous == rating.Max) {
+		rating.resetBounds(scores)
+	}
+	r.ranking.set(laptopID, rating.BayesianAverage(r.prior))
+
+	return rating.clone(), nil
 }
 
-func (r *RatingRepositoryImpl) Add(laptopID string, score float64) (*Rating, error) {
+func (r *RatingRepositoryImpl) Retract(laptopID, username string) (*Rating, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	scores := r.scores[laptopID]
+	score, ok := scores[username]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	delete(scores, username)
+	if len(scores) == 0 {
+		delete(r.scores, laptopID)
+		delete(r.rating, laptopID)
+		r.ranking.remove(laptopID)
+		return &Rating{}, nil
+	}
+
+	rating := r.rating[laptopID]
+	rating.remove(score)
+	if score == rating.Min || score == rating.Max {
+		rating.resetBounds(scores)
+	}
+	r.ranking.set(laptopID, rating.BayesianAverage(r.prior))
+
+	return rating.clone(), nil
+}
+
+// score returns the score of a user for a laptop.
+func (r *RatingRepositoryImpl) score(laptopID, username string) (float64, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	score, ok := r.scores[laptopID][username]
+	return score, ok
+}
+
+func (r *RatingRepositoryImpl) Find(laptopID string) (*Rating, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
 	rating := r.rating[laptopID]
 	if rating == nil {
-		rating = &Rating{
-			Count: 1,
-			Sum:   score,
+		return nil, ErrNotFound
+	}
+
+	return rating.clone(), nil
+}
+
+func (r *RatingRepositoryImpl) FindAll(laptopIDs []string) (map[string]*Rating, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	ratings := make(map[string]*Rating)
+	for _, laptopID := range laptopIDs {
+		rating := r.rating[laptopID]
+		if rating != nil {
+			ratings[laptopID] = rating.clone()
 		}
-	} else {
-		rating.Count++
-		rating.Sum += score
 	}
 
-	r.rating[laptopID] = rating
-	return rating, nil
+	return ratings, nil
+}
+
+// TopRated reads the ranking a page at a time and calls accept without the
+// lock, as accept looks up laptops whose callers may wait for this lock.
+func (r *RatingRepositoryImpl) TopRated(limit int, accept func(laptopID string) (bool, error)) ([]*RankedRating, error) {
+	var top []*RankedRating
+	var last *rankingEntry
+
+	for {
+		page := r.rankingPage(last)
+		for _, entry := range page {
+			if limit > 0 && len(top) >= limit {
+				return top, nil
+			}
+
+			accepted, err := accept(entry.laptopID)
+			if err != nil {
+				return nil, err
+			}
+			if !accepted {
+				continue
+			}
+
+			rating, err := r.Find(entry.laptopID)
+			if errors.Is(err, ErrNotFound) {
+				// retracted since the page was read
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			top = append(top, &RankedRating{LaptopID: entry.laptopID, Score: entry.score, Rating: rating})
+		}
+
+		if len(page) < topRatedPage || (limit > 0 && len(top) >= limit) {
+			return top, nil
+		}
+		last = &page[len(page)-1]
+	}
+}
+
+// rankingPage copies the entries ranked after the last one.
+func (r *RatingRepositoryImpl) rankingPage(last *rankingEntry) []rankingEntry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.ranking.page(last, topRatedPage)
+}
+
+func (r *RatingRepositoryImpl) SetPrior(prior RatingPrior) error {
+	err := prior.Validate()
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.prior = prior
+	r.ranking = newRatingRanking()
+	for laptopID, rating := range r.rating {
+		r.ranking.set(laptopID, rating.BayesianAverage(prior))
+	}
+	return nil
+}
+
+// each calls fn with every score under the read lock.
+func (r *RatingRepositoryImpl) each(fn func(laptopID, username string, score float64) error) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for laptopID, scores := range r.scores {
+		for username, score := range scores {
+			err := fn(laptopID, username, score)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,151 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/engine/repository/repotest"
+	_ "modernc.org/sqlite"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB opens a migrated SQLite database that lives as long as the test.
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, repository.MigrateSQL(context.Background(), db, repository.SQLite))
+	// migrating again finds nothing to do
+	require.NoError(t, repository.MigrateSQL(context.Background(), db, repository.SQLite))
+	return db
+}
+
+func TestLaptopRepositoryContract(t *testing.T) {
+	t.Parallel()
+
+	implementations := map[string]func(t *testing.T) repository.LaptopRepository{
+		"memory": func(t *testing.T) repository.LaptopRepository { return repository.NewLaptopRepository() },
+		"file": func(t *testing.T) repository.LaptopRepository {
+			repo, err := repository.NewFileLaptopRepository(t.TempDir(), repository.FileOptions{Sync: repository.SyncNever})
+			require.NoError(t, err)
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+		"sql": func(t *testing.T) repository.LaptopRepository {
+			return repository.NewSQLLaptopRepository(openTestDB(t), repository.SQLite)
+		},
+	}
+
+	for name, newRepo := range implementations {
+		newRepo := newRepo
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repotest.TestLaptopRepository(t, newRepo)
+		})
+	}
+}
+
+func TestUserRepositoryContract(t *testing.T) {
+	t.Parallel()
+
+	implementations := map[string]func(t *testing.T) repository.UserRepository{
+		"memory": func(t *testing.T) repository.UserRepository { return repository.NewUserRepository() },
+		"file": func(t *testing.T) repository.UserRepository {
+			repo, err := repository.NewFileUserRepository(t.TempDir(), repository.FileOptions{Sync: repository.SyncNever})
+			require.NoError(t, err)
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+		"sql": func(t *testing.T) repository.UserRepository {
+			return repository.NewSQLUserRepository(openTestDB(t), repository.SQLite)
+		},
+	}
+
+	for name, newRepo := range implementations {
+		newRepo := newRepo
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repotest.TestUserRepository(t, newRepo)
+		})
+	}
+}
+
+func TestRatingRepositoryContract(t *testing.T) {
+	t.Parallel()
+
+	implementations := map[string]func(t *testing.T) repository.RatingRepository{
+		"memory": func(t *testing.T) repository.RatingRepository { return repository.NewRatingRepository() },
+		"file": func(t *testing.T) repository.RatingRepository {
+			repo, err := repository.NewFileRatingRepository(t.TempDir(), repository.FileOptions{Sync: repository.SyncNever})
+			require.NoError(t, err)
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+		"sql": func(t *testing.T) repository.RatingRepository {
+			return repository.NewSQLRatingRepository(openTestDB(t), repository.SQLite)
+		},
+	}
+
+	for name, newRepo := range implementations {
+		newRepo := newRepo
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repotest.TestRatingRepository(t, newRepo)
+		})
+	}
+}
+
+func TestReviewRepositoryContract(t *testing.T) {
+	t.Parallel()
+
+	implementations := map[string]func(t *testing.T) repository.ReviewRepository{
+		"memory": func(t *testing.T) repository.ReviewRepository { return repository.NewReviewRepository() },
+		"file": func(t *testing.T) repository.ReviewRepository {
+			repo, err := repository.NewFileReviewRepository(t.TempDir(), repository.FileOptions{Sync: repository.SyncNever})
+			require.NoError(t, err)
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+		"sql": func(t *testing.T) repository.ReviewRepository {
+			return repository.NewSQLReviewRepository(openTestDB(t), repository.SQLite)
+		},
+	}
+
+	for name, newRepo := range implementations {
+		newRepo := newRepo
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repotest.TestReviewRepository(t, newRepo)
+		})
+	}
+}
+
+func TestImageRepositoryContract(t *testing.T) {
+	t.Parallel()
+
+	implementations := map[string]func(t *testing.T, imageFolder string) repository.ImageRepository{
+		"memory": func(t *testing.T, imageFolder string) repository.ImageRepository {
+			return repository.NewImageRepository(imageFolder)
+		},
+		"sql": func(t *testing.T, imageFolder string) repository.ImageRepository {
+			return repository.NewSQLImageRepository(openTestDB(t), repository.SQLite, imageFolder)
+		},
+	}
+
+	for name, newRepo := range implementations {
+		newRepo := newRepo
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			repotest.TestImageRepository(t, newRepo)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package repotest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestImageRepository checks that Save writes every image to its own file of
+// the image folder, also when called concurrently.
+func TestImageRepository(t *testing.T, newRepo func(t *testing.T, imageFolder string) repository.ImageRepository) {
+	t.Run("save", func(t *testing.T) {
+		imageFolder := t.TempDir()
+		repo := newRepo(t, imageFolder)
+
+		imageID, err := repo.Save("laptop-1", ".jpg", *bytes.NewBufferString("image"))
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(imageFolder, imageID+".jpg"))
+		require.NoError(t, err)
+		require.Equal(t, "image", string(data))
+	})
+
+	t.Run("missing_folder", func(t *testing.T) {
+		repo := newRepo(t, filepath.Join(t.TempDir(), "missing"))
+
+		_, err := repo.Save("laptop-1", ".jpg", *bytes.NewBufferString("image"))
+		require.Error(t, err)
+	})
+
+	t.Run("concurrent_saves", func(t *testing.T) {
+		imageFolder := t.TempDir()
+		repo := newRepo(t, imageFolder)
+
+		imageIDs := make(map[string]string)
+		var mutex sync.Mutex
+		runConcurrently(func(i int) {
+			content := fmt.Sprintf("image %d", i)
+			imageID, err := repo.Save("laptop-1", ".png", *bytes.NewBufferString(content))
+			assert.NoError(t, err)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			imageIDs[imageID] = content
+		})
+
+		require.Len(t, imageIDs, concurrency)
+		for imageID, content := range imageIDs {
+			data, err := os.ReadFile(filepath.Join(imageFolder, imageID+".png"))
+			require.NoError(t, err)
+			require.Equal(t, content, string(data))
+		}
+	})
+}
@@ -0,0 +1,403 @@
+// Package repotest provides conformance tests for implementations of the
+// repository interfaces. An implementation runs them against itself from its
+// own tests:
+//
+//	func TestLaptopRepository(t *testing.T) {
+//		repotest.TestLaptopRepository(t, func(t *testing.T) repository.LaptopRepository {
+//			return NewMyLaptopRepository(t.TempDir())
+//		})
+//	}
+//
+// The factory is called once per subtest and must return an empty repository.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"gitlab.com/iruldev/grpc-class/sample"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"sync"
+	"testing"
+)
+
+// concurrency is the number of goroutines of the concurrency tests.
+const concurrency = 16
+
+// TestLaptopRepository checks the semantics of Save, Find, Update, Delete,
+// Search, Facets and List, their errors, concurrent use and cancellation.
+// Search results are compared with those of the in-memory repository.
+func TestLaptopRepository(t *testing.T, newRepo func(t *testing.T) repository.LaptopRepository) {
+	t.Run("save_and_find", func(t *testing.T) {
+		repo := newRepo(t)
+
+		laptop := sample.NewLaptop()
+		require.NoError(t, repo.Save(laptop))
+
+		found, err := repo.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), found.GetVersion())
+		require.NotNil(t, found.GetUpdatedAt())
+
+		expected := protobuf.Clone(laptop).(*proto.Laptop)
+		expected.Version, expected.UpdatedAt = found.GetVersion(), found.GetUpdatedAt()
+		require.True(t, protobuf.Equal(expected, found))
+
+		// neither the saved nor the found laptop is shared with the store
+		laptop.Brand = "changed"
+		found.Name = "changed"
+		stored, err := repo.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.True(t, protobuf.Equal(expected, stored))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		repo := newRepo(t)
+
+		laptop := sample.NewLaptop()
+		require.NoError(t, repo.Save(laptop))
+
+		testCases := []struct {
+			name string
+			call func() error
+			err  error
+		}{
+			{"save_existing", func() error { return repo.Save(laptop) }, repository.ErrAlreadyExists},
+			{"find_missing", func() error {
+				_, err := repo.Find("missing")
+				return err
+			}, repository.ErrNotFound},
+			{"update_missing", func() error {
+				_, err := repo.Update(sample.NewLaptop())
+				return err
+			}, repository.ErrNotFound},
+			{"update_stale", func() error {
+				other := protobuf.Clone(laptop).(*proto.Laptop)
+				other.Version = 2
+				_, err := repo.Update(other)
+				return err
+			}, repository.ErrVersionMismatch},
+			{"delete_missing", func() error { return repo.Delete("missing", 0) }, repository.ErrNotFound},
+			{"delete_stale", func() error { return repo.Delete(laptop.GetId(), 2) }, repository.ErrVersionMismatch},
+		}
+
+		for _, tc := range testCases {
+			require.ErrorIs(t, tc.call(), tc.err, tc.name)
+		}
+	})
+
+	t.Run("update_and_delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		laptop := sample.NewLaptop()
+		require.NoError(t, repo.Save(laptop))
+
+		found, err := repo.Find(laptop.GetId())
+		require.NoError(t, err)
+		found.PriceUsd = 1234
+		found.Gpus = found.Gpus[:1]
+
+		updated, err := repo.Update(found)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), updated.GetVersion())
+		require.Equal(t, 1234.0, updated.GetPriceUsd())
+
+		// a zero version skips the check
+		found.Version = 0
+		updated, err = repo.Update(found)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), updated.GetVersion())
+
+		stored, err := repo.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.True(t, protobuf.Equal(updated, stored))
+
+		require.NoError(t, repo.Delete(laptop.GetId(), 3))
+		_, err = repo.Find(laptop.GetId())
+		require.ErrorIs(t, err, repository.ErrNotFound)
+		require.NoError(t, repo.Save(laptop))
+	})
+
+	t.Run("search", func(t *testing.T) {
+		testLaptopSearch(t, newRepo(t))
+	})
+
+	t.Run("callback_error", func(t *testing.T) {
+		repo := newRepo(t)
+		saveLaptops(t, repo, 10)
+
+		stop := errors.New("stop")
+		calls := 0
+		found := func(laptop *proto.Laptop) error {
+			calls++
+			return stop
+		}
+
+		require.ErrorIs(t, repo.Search(context.Background(), nil, repository.SearchOptions{}, found), stop)
+		require.Equal(t, 1, calls)
+		require.ErrorIs(t, repo.List(context.Background(), found), stop)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("canceled_context", func(t *testing.T) {
+		repo := newRepo(t)
+		saveLaptops(t, repo, 10)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		found := func(laptop *proto.Laptop) error {
+			return nil
+		}
+		err := repo.Search(ctx, nil, repository.SearchOptions{}, found)
+		require.Equal(t, codes.Canceled, status.Code(err))
+		err = repo.List(ctx, found)
+		require.Equal(t, codes.Canceled, status.Code(err))
+		_, err = repo.Facets(ctx, nil, repository.SearchOptions{})
+		require.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("concurrent_saves", func(t *testing.T) {
+		repo := newRepo(t)
+
+		laptop := sample.NewLaptop()
+		var saved, exists int
+		var mutex sync.Mutex
+		runConcurrently(func(i int) {
+			// every goroutine saves its own laptop and the shared one
+			assert.NoError(t, repo.Save(sample.NewLaptop()))
+
+			err := repo.Save(protobuf.Clone(laptop).(*proto.Laptop))
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err == nil:
+				saved++
+			case errors.Is(err, repository.ErrAlreadyExists):
+				exists++
+			default:
+				assert.NoError(t, err)
+			}
+		})
+
+		require.Equal(t, 1, saved)
+		require.Equal(t, concurrency-1, exists)
+		require.Len(t, listLaptops(t, repo), concurrency+1)
+	})
+
+	t.Run("concurrent_updates", func(t *testing.T) {
+		repo := newRepo(t)
+
+		laptop := sample.NewLaptop()
+		require.NoError(t, repo.Save(laptop))
+		stored, err := repo.Find(laptop.GetId())
+		require.NoError(t, err)
+
+		// all goroutines update version 1, exactly one of them wins
+		var updated, mismatched int
+		var mutex sync.Mutex
+		runConcurrently(func(i int) {
+			other := protobuf.Clone(stored).(*proto.Laptop)
+			other.PriceUsd = float64(i)
+
+			_, err := repo.Update(other)
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err == nil:
+				updated++
+			case errors.Is(err, repository.ErrVersionMismatch):
+				mismatched++
+			default:
+				assert.NoError(t, err)
+			}
+
+			err = repo.Search(context.Background(), nil, repository.SearchOptions{}, func(laptop *proto.Laptop) error {
+				return nil
+			})
+			assert.NoError(t, err)
+		})
+
+		require.Equal(t, 1, updated)
+		require.Equal(t, concurrency-1, mismatched)
+
+		stored, err = repo.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), stored.GetVersion())
+	})
+
+	t.Run("concurrent_deletes", func(t *testing.T) {
+		repo := newRepo(t)
+
+		laptop := sample.NewLaptop()
+		require.NoError(t, repo.Save(protobuf.Clone(laptop).(*proto.Laptop)))
+
+		// all goroutines delete version 1, exactly one of them wins
+		var deleted int
+		var mutex sync.Mutex
+		runConcurrently(func(i int) {
+			err := repo.Delete(laptop.GetId(), 1)
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err == nil:
+				deleted++
+			case errors.Is(err, repository.ErrNotFound), errors.Is(err, repository.ErrVersionMismatch):
+			default:
+				assert.NoError(t, err)
+			}
+		})
+
+		require.Equal(t, 1, deleted)
+		_, err := repo.Find(laptop.GetId())
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		// nothing of the deleted laptop is left behind
+		require.NoError(t, repo.Save(protobuf.Clone(laptop).(*proto.Laptop)))
+		stored, err := repo.Find(laptop.GetId())
+		require.NoError(t, err)
+		require.Len(t, stored.GetGpus(), len(laptop.GetGpus()))
+	})
+}
+
+// runConcurrently calls fn from concurrent goroutines, which must report
+// failures with assert rather than require.
+func runConcurrently(fn func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// saveLaptops saves n sample laptops and returns them as stored.
+func saveLaptops(t *testing.T, repo repository.LaptopRepository, n int) map[string]*proto.Laptop {
+	laptops := make(map[string]*proto.Laptop)
+	for i := 0; i < n; i++ {
+		laptop := sample.NewLaptop()
+		if i%7 == 0 {
+			laptop.Weight = nil
+		}
+		require.NoError(t, repo.Save(laptop))
+
+		stored, err := repo.Find(laptop.GetId())
+		require.NoError(t, err)
+		laptops[laptop.GetId()] = stored
+	}
+	return laptops
+}
+
+func listLaptops(t *testing.T, repo repository.LaptopRepository) map[string]*proto.Laptop {
+	laptops := make(map[string]*proto.Laptop)
+	err := repo.List(context.Background(), func(laptop *proto.Laptop) error {
+		laptops[laptop.GetId()] = laptop
+		return nil
+	})
+	require.NoError(t, err)
+	return laptops
+}
+
+func searchIDs(t *testing.T, repo repository.LaptopRepository, filter *proto.Filter, options repository.SearchOptions) []string {
+	var ids []string
+	err := repo.Search(context.Background(), filter, options, func(laptop *proto.Laptop) error {
+		ids = append(ids, laptop.GetId())
+		return nil
+	})
+	require.NoError(t, err)
+	return ids
+}
+
+func testLaptopSearch(t *testing.T, repo repository.LaptopRepository) {
+	laptops := saveLaptops(t, repo, 200)
+
+	listed := listLaptops(t, repo)
+	require.Len(t, listed, len(laptops))
+	for id, laptop := range laptops {
+		require.True(t, protobuf.Equal(laptop, listed[id]), id)
+	}
+
+	reference := repository.NewLaptopRepository()
+	for _, laptop := range laptops {
+		require.NoError(t, reference.Save(laptop))
+	}
+
+	parseQuery := func(text string) repository.Query {
+		query, err := repository.ParseQuery(text)
+		require.NoError(t, err)
+		return query
+	}
+	parseOrder := func(text string) repository.Order {
+		order, err := repository.ParseOrder(text)
+		require.NoError(t, err)
+		return order
+	}
+	rating := func(laptopID string) float64 {
+		return float64(len(laptopID) % 5)
+	}
+
+	var cursor *proto.Laptop
+	for _, laptop := range laptops {
+		cursor = laptop
+		break
+	}
+
+	minPrice, maxPrice := 1800.0, 2600.0
+	minCores, minGhz := uint32(4), 2.5
+	minInch, maxInch := float32(14), float32(16)
+	minYear, maxYear := uint32(2017), uint32(2019)
+	touch, backlit := true, false
+	testCases := []struct {
+		name    string
+		filter  *proto.Filter
+		options repository.SearchOptions
+	}{
+		{"nil_filter", nil, repository.SearchOptions{}},
+		{"empty_filter", &proto.Filter{}, repository.SearchOptions{}},
+		{"price_range", &proto.Filter{MinPriceUsd: &minPrice, MaxPriceUsd: &maxPrice}, repository.SearchOptions{}},
+		{"cpu_and_ram", &proto.Filter{MinCpuCores: &minCores, MinCpuGhz: &minGhz, MinRam: &proto.Memory{Value: 16, Unit: proto.Memory_GIGABYTE}}, repository.SearchOptions{}},
+		{"brands_and_years", &proto.Filter{Brands: []string{"dell", "APPLE"}, MinReleaseYear: &minYear, MaxReleaseYear: &maxYear}, repository.SearchOptions{}},
+		{"gpu", &proto.Filter{GpuBrands: []string{"nvidia"}, MinGpuMemory: &proto.Memory{Value: 4, Unit: proto.Memory_GIGABYTE}}, repository.SearchOptions{}},
+		{"storage", &proto.Filter{MinSsdCapacity: &proto.Memory{Value: 512, Unit: proto.Memory_GIGABYTE}, MinHddCapacity: &proto.Memory{Value: 2, Unit: proto.Memory_TERABYTE}}, repository.SearchOptions{}},
+		{"screen", &proto.Filter{MinScreenInch: &minInch, MaxScreenInch: &maxInch, MinResolution: &proto.Screen_Resolution{Width: 1920, Height: 1080}}, repository.SearchOptions{}},
+		{"panel_and_touch", &proto.Filter{Panels: []proto.Screen_Panel{proto.Screen_OLED}, Multitouch: &touch}, repository.SearchOptions{}},
+		{"keyboard", &proto.Filter{KeyboardLayouts: []proto.Keyboard_Layout{proto.Keyboard_QWERTY, proto.Keyboard_AZERTY}, KeyboardBacklit: &backlit}, repository.SearchOptions{}},
+		{"weight_kg", &proto.Filter{MaxWeight: &proto.Filter_MaxWeightKg{MaxWeightKg: 2}}, repository.SearchOptions{}},
+		{"weight_lb", &proto.Filter{MaxWeight: &proto.Filter_MaxWeightLb{MaxWeightLb: 4.5}}, repository.SearchOptions{}},
+		{"query", nil, repository.SearchOptions{Query: parseQuery("ram >= 16GB and (brand = Dell or gpu.brand = AMD)")}},
+		{"text", nil, repository.SearchOptions{Text: "core"}},
+		{"order", nil, repository.SearchOptions{Order: parseOrder("release_year desc, price")}},
+		{"order_by_rating", nil, repository.SearchOptions{Order: parseOrder("rating desc"), Rating: rating}},
+		{"limit", &proto.Filter{MaxPriceUsd: &maxPrice}, repository.SearchOptions{Order: parseOrder("price desc"), Limit: 10}},
+		{"offset", &proto.Filter{MaxPriceUsd: &maxPrice}, repository.SearchOptions{Order: parseOrder("price desc"), Limit: 10, Offset: 5}},
+		{"offset_without_limit", nil, repository.SearchOptions{Order: parseOrder("release_year, price"), Offset: 190}},
+		{"after", nil, repository.SearchOptions{Order: parseOrder("price desc"), Limit: 10, After: parseOrder("price desc").Values(cursor, nil, nil)}},
+		{"combined", nil, repository.SearchOptions{Query: parseQuery("ram >= 8GB"), Text: "a", Order: parseOrder("price desc"), Limit: 10}},
+	}
+
+	for _, tc := range testCases {
+		expected := searchIDs(t, reference, tc.filter, tc.options)
+		actual := searchIDs(t, repo, tc.filter, tc.options)
+		message := fmt.Sprintf("%s: %v", tc.name, tc.filter)
+		if len(tc.options.Order) == 0 && tc.options.Text == "" {
+			require.ElementsMatch(t, expected, actual, message)
+		} else {
+			require.Equal(t, expected, actual, message)
+		}
+
+		facets, err := repo.Facets(context.Background(), tc.filter, tc.options)
+		require.NoError(t, err)
+		expectedFacets, err := reference.Facets(context.Background(), tc.filter, tc.options)
+		require.NoError(t, err)
+		require.Equal(t, expectedFacets, facets, message)
+	}
+}
@@ -0,0 +1,274 @@
+package repotest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"math"
+	"sort"
+	"testing"
+)
+
+// TestRatingRepository checks the semantics of Rate, Retract, Find, FindAll,
+// TopRated and SetPrior, their errors and concurrent use.
+func TestRatingRepository(t *testing.T, newRepo func(t *testing.T) repository.RatingRepository) {
+	t.Run("rate_and_find", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Find("laptop-1")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		testCases := []struct {
+			laptopID string
+			username string
+			score    float64
+			rating   repository.Rating
+		}{
+			{"laptop-1", "alice", 4, repository.Rating{Count: 1, Sum: 4, Min: 4, Max: 4, Histogram: map[int]uint32{4: 1}}},
+			{"laptop-1", "bob", 2.5, repository.Rating{Count: 2, Sum: 6.5, Min: 2.5, Max: 4, Histogram: map[int]uint32{2: 1, 4: 1}}},
+			{"laptop-2", "alice", 1, repository.Rating{Count: 1, Sum: 1, Min: 1, Max: 1, Histogram: map[int]uint32{1: 1}}},
+			{"laptop-1", "carol", 10, repository.Rating{Count: 3, Sum: 16.5, Min: 2.5, Max: 10, Histogram: map[int]uint32{2: 1, 4: 1, 10: 1}}},
+			// a repeated rating replaces the previous score of the user
+			{"laptop-1", "bob", 2, repository.Rating{Count: 3, Sum: 16, Min: 2, Max: 10, Histogram: map[int]uint32{2: 1, 4: 1, 10: 1}}},
+			{"laptop-1", "carol", 6, repository.Rating{Count: 3, Sum: 12, Min: 2, Max: 6, Histogram: map[int]uint32{2: 1, 4: 1, 6: 1}}},
+		}
+
+		for _, tc := range testCases {
+			rating, err := repo.Rate(tc.laptopID, tc.username, tc.score)
+			require.NoError(t, err)
+			require.Equal(t, tc.rating, *rating)
+
+			// the returned rating is not shared with the store
+			rating.Count = 100
+			rating.Histogram[0] = 100
+		}
+
+		expected := testCases[len(testCases)-1].rating
+		rating, err := repo.Find("laptop-1")
+		require.NoError(t, err)
+		require.Equal(t, expected, *rating)
+		require.Equal(t, 12.0/3, rating.Average())
+
+		rating.Sum = 0
+		rating.Histogram[2] = 0
+		rating, err = repo.Find("laptop-1")
+		require.NoError(t, err)
+		require.Equal(t, expected, *rating)
+	})
+
+	t.Run("retract", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Retract("laptop-1", "alice")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		_, err = repo.Rate("laptop-1", "alice", 2)
+		require.NoError(t, err)
+		_, err = repo.Rate("laptop-1", "bob", 9)
+		require.NoError(t, err)
+		_, err = repo.Rate("laptop-1", "carol", 5.5)
+		require.NoError(t, err)
+
+		_, err = repo.Retract("laptop-1", "dave")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.Retract("laptop-2", "alice")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		// the bounds are recomputed from the remaining scores
+		rating, err := repo.Retract("laptop-1", "bob")
+		require.NoError(t, err)
+		require.Equal(t, repository.Rating{Count: 2, Sum: 7.5, Min: 2, Max: 5.5, Histogram: map[int]uint32{2: 1, 5: 1}}, *rating)
+
+		rating, err = repo.Retract("laptop-1", "alice")
+		require.NoError(t, err)
+		require.Equal(t, repository.Rating{Count: 1, Sum: 5.5, Min: 5.5, Max: 5.5, Histogram: map[int]uint32{5: 1}}, *rating)
+
+		_, err = repo.Retract("laptop-1", "alice")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		// retracting the last score removes the rating
+		rating, err = repo.Retract("laptop-1", "carol")
+		require.NoError(t, err)
+		require.Equal(t, repository.Rating{}, *rating)
+
+		_, err = repo.Find("laptop-1")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		// the user can rate again
+		rating, err = repo.Rate("laptop-1", "carol", 3)
+		require.NoError(t, err)
+		require.Equal(t, repository.Rating{Count: 1, Sum: 3, Min: 3, Max: 3, Histogram: map[int]uint32{3: 1}}, *rating)
+	})
+
+	t.Run("non_finite_scores", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Rate("laptop-1", "alice", 4)
+		require.NoError(t, err)
+
+		for _, score := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+			_, err := repo.Rate("laptop-1", "bob", score)
+			require.Error(t, err, score)
+			_, err = repo.Rate("laptop-2", "alice", score)
+			require.Error(t, err, score)
+		}
+
+		rating, err := repo.Find("laptop-1")
+		require.NoError(t, err)
+		require.Equal(t, repository.Rating{Count: 1, Sum: 4, Min: 4, Max: 4, Histogram: map[int]uint32{4: 1}}, *rating)
+		_, err = repo.Find("laptop-2")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("find_all", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.Rate("laptop-1", "alice", 4)
+		require.NoError(t, err)
+		_, err = repo.Rate("laptop-2", "alice", 7.5)
+		require.NoError(t, err)
+		_, err = repo.Rate("laptop-3", "alice", 1)
+		require.NoError(t, err)
+		_, err = repo.Retract("laptop-3", "alice")
+		require.NoError(t, err)
+
+		ratings, err := repo.FindAll([]string{"laptop-2", "missing", "laptop-1", "laptop-3"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]*repository.Rating{
+			"laptop-1": {Count: 1, Sum: 4, Min: 4, Max: 4, Histogram: map[int]uint32{4: 1}},
+			"laptop-2": {Count: 1, Sum: 7.5, Min: 7.5, Max: 7.5, Histogram: map[int]uint32{7: 1}},
+		}, ratings)
+
+		ratings, err = repo.FindAll(nil)
+		require.NoError(t, err)
+		require.Empty(t, ratings)
+	})
+
+	t.Run("top_rated", func(t *testing.T) {
+		repo := newRepo(t)
+
+		scores := map[string][]float64{
+			"laptop-a": {10},
+			"laptop-b": {9, 9, 9, 9, 9, 9, 9, 9, 9, 9},
+			"laptop-c": {3, 4},
+			"laptop-d": {6.25},
+			"laptop-e": {10},
+		}
+		for laptopID, laptopScores := range scores {
+			for i, score := range laptopScores {
+				_, err := repo.Rate(laptopID, fmt.Sprintf("user-%d", i), score)
+				require.NoError(t, err)
+			}
+		}
+
+		// a single 10 ranks below ten 9s, ties are broken by laptop ID
+		top, err := repo.TopRated(0, acceptAll)
+		require.NoError(t, err)
+		requireRanking(t, []string{"laptop-b", "laptop-a", "laptop-e", "laptop-d", "laptop-c"}, top)
+		require.InDelta(t, (5*5.5+90)/15, top[0].Score, 1e-9)
+		require.Equal(t, repository.Rating{Count: 10, Sum: 90, Min: 9, Max: 9, Histogram: map[int]uint32{9: 10}}, *top[0].Rating)
+
+		top, err = repo.TopRated(2, acceptAll)
+		require.NoError(t, err)
+		requireRanking(t, []string{"laptop-b", "laptop-a"}, top)
+
+		top, err = repo.TopRated(2, func(laptopID string) (bool, error) {
+			return laptopID != "laptop-a" && laptopID != "laptop-b", nil
+		})
+		require.NoError(t, err)
+		requireRanking(t, []string{"laptop-e", "laptop-d"}, top)
+
+		errAccept := errors.New("cannot accept")
+		_, err = repo.TopRated(0, func(laptopID string) (bool, error) { return false, errAccept })
+		require.ErrorIs(t, err, errAccept)
+
+		// the ranking follows the changes of the ratings
+		_, err = repo.Retract("laptop-e", "user-0")
+		require.NoError(t, err)
+		_, err = repo.Rate("laptop-c", "user-0", 10)
+		require.NoError(t, err)
+
+		top, err = repo.TopRated(0, acceptAll)
+		require.NoError(t, err)
+		requireRanking(t, []string{"laptop-b", "laptop-a", "laptop-c", "laptop-d"}, top)
+
+		// without weight the laptops are ranked by their plain average
+		require.NoError(t, repo.SetPrior(repository.RatingPrior{Mean: 5, Weight: 0}))
+		top, err = repo.TopRated(0, acceptAll)
+		require.NoError(t, err)
+		requireRanking(t, []string{"laptop-a", "laptop-b", "laptop-c", "laptop-d"}, top)
+		require.Equal(t, 7.0, top[2].Score)
+
+		_, err = repo.Rate("laptop-d", "user-1", 9.75)
+		require.NoError(t, err)
+		top, err = repo.TopRated(1, acceptAll)
+		require.NoError(t, err)
+		requireRanking(t, []string{"laptop-a"}, top)
+
+		require.Error(t, repo.SetPrior(repository.RatingPrior{Mean: 5, Weight: -1}))
+	})
+
+	t.Run("top_rated_many", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var expected []string
+		for i := 0; i < 120; i++ {
+			laptopID := fmt.Sprintf("laptop-%03d", i)
+			_, err := repo.Rate(laptopID, "alice", float64(1+i%10))
+			require.NoError(t, err)
+			if i%2 == 1 {
+				expected = append(expected, laptopID)
+			}
+		}
+		sort.Slice(expected, func(i, j int) bool {
+			a, b := expected[i], expected[j]
+			if a[len(a)-1] != b[len(b)-1] {
+				return a[len(a)-1] > b[len(b)-1]
+			}
+			return a < b
+		})
+
+		top, err := repo.TopRated(0, func(laptopID string) (bool, error) {
+			return (laptopID[len(laptopID)-1]-'0')%2 == 1, nil
+		})
+		require.NoError(t, err)
+		requireRanking(t, expected, top)
+	})
+
+	t.Run("concurrent_rates", func(t *testing.T) {
+		repo := newRepo(t)
+
+		runConcurrently(func(i int) {
+			username := fmt.Sprintf("user-%d", i)
+			_, err := repo.Rate("laptop-1", username, 2)
+			assert.NoError(t, err)
+			// the second rating of each user replaces the first
+			_, err = repo.Rate("laptop-1", username, 3)
+			assert.NoError(t, err)
+		})
+
+		rating, err := repo.Find("laptop-1")
+		require.NoError(t, err)
+		require.Equal(t, repository.Rating{
+			Count:     concurrency,
+			Sum:       3 * concurrency,
+			Min:       3,
+			Max:       3,
+			Histogram: map[int]uint32{3: concurrency},
+		}, *rating)
+	})
+}
+
+func acceptAll(laptopID string) (bool, error) {
+	return true, nil
+}
+
+func requireRanking(t *testing.T, expected []string, top []*repository.RankedRating) {
+	laptopIDs := make([]string, 0, len(top))
+	for _, ranked := range top {
+		laptopIDs = append(laptopIDs, ranked.LaptopID)
+	}
+	require.Equal(t, expected, laptopIDs)
+}
@@ -0,0 +1,207 @@
+package repotest
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/proto"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestReviewRepository checks the semantics of Save, Find, FindByAuthor,
+// Update, SetHidden, Delete and List, their errors and concurrent use.
+func TestReviewRepository(t *testing.T, newRepo func(t *testing.T) repository.ReviewRepository) {
+	t.Run("save_and_find", func(t *testing.T) {
+		repo := newRepo(t)
+
+		review := newReview("review-1", "laptop-1", "alice", 0)
+		require.NoError(t, repo.Save(review))
+		require.ErrorIs(t, repo.Save(review), repository.ErrAlreadyExists)
+
+		// an author reviews a laptop once
+		other := newReview("review-2", "laptop-1", "alice", 1)
+		require.ErrorIs(t, repo.Save(other), repository.ErrAlreadyExists)
+		other.LaptopId = "laptop-2"
+		require.NoError(t, repo.Save(other))
+
+		found, err := repo.Find("review-1")
+		require.NoError(t, err)
+		require.True(t, protobuf.Equal(review, found))
+
+		// the found review is not shared with the store
+		found.Title = "changed"
+		found, err = repo.Find("review-1")
+		require.NoError(t, err)
+		require.Equal(t, review.Title, found.Title)
+
+		found, err = repo.FindByAuthor("laptop-2", "alice")
+		require.NoError(t, err)
+		require.True(t, protobuf.Equal(other, found))
+
+		_, err = repo.Find("missing")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = repo.FindByAuthor("laptop-1", "bob")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+	})
+
+	t.Run("update_and_delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		review := newReview("review-1", "laptop-1", "alice", 0)
+		require.NoError(t, repo.Save(review))
+
+		edited := newReview("review-1", "laptop-2", "bob", 0)
+		edited.Title = "edited"
+		edited.Score = 2
+		edited.EditedAt = timestamppb.New(baseTime.Add(time.Hour))
+		edited.Hidden = true
+		require.NoError(t, repo.Update(edited))
+
+		// the laptop, the author and the hidden flag stay
+		found, err := repo.FindByAuthor("laptop-1", "alice")
+		require.NoError(t, err)
+		edited.LaptopId = "laptop-1"
+		edited.Author = "alice"
+		edited.Hidden = false
+		require.True(t, protobuf.Equal(edited, found))
+
+		require.ErrorIs(t, repo.Update(newReview("missing", "laptop-1", "bob", 0)), repository.ErrNotFound)
+
+		hidden, err := repo.SetHidden("review-1", true)
+		require.NoError(t, err)
+		edited.Hidden = true
+		require.True(t, protobuf.Equal(edited, hidden))
+		found, err = repo.Find("review-1")
+		require.NoError(t, err)
+		require.True(t, protobuf.Equal(edited, found))
+
+		_, err = repo.SetHidden("missing", true)
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		require.NoError(t, repo.Delete("review-1"))
+		require.ErrorIs(t, repo.Delete("review-1"), repository.ErrNotFound)
+		_, err = repo.Find("review-1")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+
+		// the author can review the laptop again
+		require.NoError(t, repo.Save(newReview("review-2", "laptop-1", "alice", 0)))
+	})
+
+	t.Run("list", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// review-0 and review-1 are created at the same time
+		offsets := []int{0, 0, 3, 1, 2}
+		for i, offset := range offsets {
+			review := newReview(fmt.Sprintf("review-%d", i), "laptop-1", fmt.Sprintf("user-%d", i), offset)
+			review.Hidden = i == 3
+			require.NoError(t, repo.Save(review))
+		}
+		require.NoError(t, repo.Save(newReview("other", "laptop-2", "user-0", 5)))
+
+		reviews, err := repo.List("laptop-1", repository.ReviewListOptions{})
+		require.NoError(t, err)
+		requireReviewIDs(t, []string{"review-2", "review-4", "review-0", "review-1"}, reviews)
+
+		reviews, err = repo.List("laptop-1", repository.ReviewListOptions{IncludeHidden: true})
+		require.NoError(t, err)
+		requireReviewIDs(t, []string{"review-2", "review-4", "review-3", "review-0", "review-1"}, reviews)
+
+		// pages start after the last review of the previous page
+		var ids []string
+		options := repository.ReviewListOptions{Limit: 2, IncludeHidden: true}
+		for {
+			page, err := repo.List("laptop-1", options)
+			require.NoError(t, err)
+			if len(page) == 0 {
+				break
+			}
+			require.LessOrEqual(t, len(page), 2)
+			for _, review := range page {
+				ids = append(ids, review.Id)
+			}
+			options.After = repository.PositionOf(page[len(page)-1])
+		}
+		require.Equal(t, []string{"review-2", "review-4", "review-3", "review-0", "review-1"}, ids)
+
+		reviews, err = repo.List("missing", repository.ReviewListOptions{})
+		require.NoError(t, err)
+		require.Empty(t, reviews)
+	})
+
+	t.Run("concurrent_saves", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// two goroutines review the laptop as each user
+		var mutex sync.Mutex
+		saved := 0
+		runConcurrently(func(i int) {
+			review := newReview(fmt.Sprintf("review-%d", i), "laptop-1", fmt.Sprintf("user-%d", i/2), i)
+			err := repo.Save(review)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err == nil {
+				saved++
+			} else {
+				assert.ErrorIs(t, err, repository.ErrAlreadyExists)
+			}
+		})
+		require.Equal(t, concurrency/2, saved)
+
+		reviews, err := repo.List("laptop-1", repository.ReviewListOptions{})
+		require.NoError(t, err)
+		require.Len(t, reviews, concurrency/2)
+	})
+
+	t.Run("concurrent_edits_and_hides", func(t *testing.T) {
+		repo := newRepo(t)
+		require.NoError(t, repo.Save(newReview("review-1", "laptop-1", "alice", 0)))
+
+		// half of the goroutines edit the review, the other half hide it
+		runConcurrently(func(i int) {
+			if i%2 == 0 {
+				edited := newReview("review-1", "laptop-1", "alice", 0)
+				edited.Title = fmt.Sprintf("edit %d", i)
+				assert.NoError(t, repo.Update(edited))
+				return
+			}
+			_, err := repo.SetHidden("review-1", true)
+			assert.NoError(t, err)
+		})
+
+		// no edit shows the review again
+		found, err := repo.Find("review-1")
+		require.NoError(t, err)
+		require.True(t, found.Hidden)
+	})
+}
+
+var baseTime = time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+// newReview returns a review created the given number of minutes after
+// baseTime.
+func newReview(id, laptopID, author string, minutes int) *proto.Review {
+	return &proto.Review{
+		Id:        id,
+		LaptopId:  laptopID,
+		Author:    author,
+		Title:     "Review of " + laptopID,
+		Body:      "Written by " + author,
+		Score:     7.5,
+		CreatedAt: timestamppb.New(baseTime.Add(time.Duration(minutes) * time.Minute)),
+	}
+}
+
+func requireReviewIDs(t *testing.T, expected []string, reviews []*proto.Review) {
+	ids := make([]string, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.Id)
+	}
+	require.Equal(t, expected, ids)
+}
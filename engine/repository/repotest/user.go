@@ -0,0 +1,85 @@
+package repotest
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/model/entity"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"sync"
+	"testing"
+)
+
+// TestUserRepository checks the semantics of Save, Find and Count, their
+// errors and concurrent use.
+func TestUserRepository(t *testing.T, newRepo func(t *testing.T) repository.UserRepository) {
+	t.Run("save_and_find", func(t *testing.T) {
+		repo := newRepo(t)
+
+		count, err := repo.Count()
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		user, err := entity.NewUser("user1", "secret", "user")
+		require.NoError(t, err)
+		require.NoError(t, repo.Save(user))
+		require.ErrorIs(t, repo.Save(user), repository.ErrAlreadyExists)
+
+		found, err := repo.Find("user1")
+		require.NoError(t, err)
+		require.Equal(t, user, found)
+		require.True(t, found.IsCorrectPassword("secret"))
+
+		// the found user is not shared with the store
+		found.Role = "admin"
+		found, err = repo.Find("user1")
+		require.NoError(t, err)
+		require.Equal(t, "user", found.Role)
+
+		count, err = repo.Count()
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("find_missing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		found, err := repo.Find("missing")
+		require.ErrorIs(t, err, repository.ErrNotFound)
+		require.Nil(t, found)
+	})
+
+	t.Run("concurrent_saves", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// two goroutines save each user
+		users := make([]*entity.User, concurrency/2)
+		for i := range users {
+			users[i] = &entity.User{Username: fmt.Sprintf("user%d", i), HashedPassword: "hash", Role: "user"}
+		}
+
+		var saved, exists int
+		var mutex sync.Mutex
+		runConcurrently(func(i int) {
+			err := repo.Save(users[i%len(users)])
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err == nil:
+				saved++
+			case errors.Is(err, repository.ErrAlreadyExists):
+				exists++
+			default:
+				assert.NoError(t, err)
+			}
+		})
+
+		require.Equal(t, len(users), saved)
+		require.Equal(t, concurrency-len(users), exists)
+
+		count, err := repo.Count()
+		require.NoError(t, err)
+		require.Equal(t, len(users), count)
+	})
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"gitlab.com/iruldev/grpc-class/proto"
+	protobuf "google.golang.org/protobuf/proto"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReviewRepository stores the reviews of the laptops, at most one per author
+// and laptop. Find, FindByAuthor and List return copies the caller may
+// modify.
+type ReviewRepository interface {
+	// Save stores a new review, or returns ErrAlreadyExists if its ID is
+	// taken or its author has reviewed the laptop already.
+	Save(review *proto.Review) error
+	Find(id string) (*proto.Review, error)
+	// FindByAuthor returns the review of a laptop by a user.
+	FindByAuthor(laptopID, author string) (*proto.Review, error)
+	// Update replaces a stored review, its laptop, author and hidden flag
+	// cannot change.
+	Update(review *proto.Review) error
+	// SetHidden hides or shows a review and returns it.
+	SetHidden(id string, hidden bool) (*proto.Review, error)
+	Delete(id string) error
+	// List returns the reviews of a laptop, the newest first.
+	List(laptopID string, options ReviewListOptions) ([]*proto.Review, error)
+}
+
+// ReviewListOptions selects a page of reviews.
+type ReviewListOptions struct {
+	// After is the position of the last review of the previous page, the
+	// page starts with the first review after it.
+	After *ReviewPosition
+	// Limit caps the number of reviews, zero means no limit.
+	Limit int
+	// IncludeHidden lists the hidden reviews too.
+	IncludeHidden bool
+}
+
+// ReviewPosition is the position of a review in a list, the reviews are
+// ordered by decreasing creation time and then by ID.
+type ReviewPosition struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// PositionOf returns the position of a review.
+func PositionOf(review *proto.Review) *ReviewPosition {
+	return &ReviewPosition{CreatedAt: review.GetCreatedAt().AsTime(), ID: review.GetId()}
+}
+
+// Before reports whether the position comes before the other one.
+func (p *ReviewPosition) Before(other *ReviewPosition) bool {
+	if !p.CreatedAt.Equal(other.CreatedAt) {
+		return p.CreatedAt.After(other.CreatedAt)
+	}
+	return p.ID < other.ID
+}
+
+type ReviewRepositoryImpl struct {
+	mutex   sync.RWMutex
+	reviews map[string]*proto.Review
+	// authors are keyed by laptop ID and then by author, to the review ID
+	authors map[string]map[string]string
+}
+
+func NewReviewRepository() ReviewRepository {
+	return newReviewRepositoryImpl()
+}
+
+func newReviewRepositoryImpl() *ReviewRepositoryImpl {
+	return &ReviewRepositoryImpl{
+		reviews: make(map[string]*proto.Review),
+		authors: make(map[string]map[string]string),
+	}
+}
+
+func (r *ReviewRepositoryImpl) Save(review *proto.Review) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.reviews[review.Id] != nil {
+		return ErrAlreadyExists
+	}
+	if _, ok := r.authors[review.LaptopId][review.Author]; ok {
+		return ErrAlreadyExists
+	}
+
+	r.insert(cloneReview(review))
+	return nil
+}
+
+func (r *ReviewRepositoryImpl) Find(id string) (*proto.Review, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	review := r.reviews[id]
+	if review == nil {
+		return nil, ErrNotFound
+	}
+
+	return cloneReview(review), nil
+}
+
+func (r *ReviewRepositoryImpl) FindByAuthor(laptopID, author string) (*proto.Review, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	id, ok := r.authors[laptopID][author]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return cloneReview(r.reviews[id]), nil
+}
+
+func (r *ReviewRepositoryImpl) Update(review *proto.Review) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.reviews[review.Id]
+	if current == nil {
+		return ErrNotFound
+	}
+
+	other := cloneReview(review)
+	other.LaptopId = current.LaptopId
+	other.Author = current.Author
+	other.Hidden = current.Hidden
+	r.reviews[other.Id] = other
+	return nil
+}
+
+func (r *ReviewRepositoryImpl) SetHidden(id string, hidden bool) (*proto.Review, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current := r.reviews[id]
+	if current == nil {
+		return nil, ErrNotFound
+	}
+
+	other := cloneReview(current)
+	other.Hidden = hidden
+	r.reviews[id] = other
+	return cloneReview(other), nil
+}
+
+func (r *ReviewRepositoryImpl) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.reviews[id] == nil {
+		return ErrNotFound
+	}
+
+	r.delete(id)
+	return nil
+}
+
+func (r *ReviewRepositoryImpl) List(laptopID string, options ReviewListOptions) ([]*proto.Review, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var reviews []*proto.Review
+	for _, id := range r.authors[laptopID] {
+		review := r.reviews[id]
+		if review.Hidden && !options.IncludeHidden {
+			continue
+		}
+		if options.After != nil && !options.After.Before(PositionOf(review)) {
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+
+	sort.Slice(reviews, func(i, j int) bool {
+		return PositionOf(reviews[i]).Before(PositionOf(reviews[j]))
+	})
+	if options.Limit > 0 && len(reviews) > options.Limit {
+		reviews = reviews[:options.Limit]
+	}
+
+	for i, review := range reviews {
+		reviews[i] = cloneReview(review)
+	}
+	return reviews, nil
+}
+
+// put stores a review as it is, replacing any review with the same ID. It
+// restores reviews from persistent storage.
+func (r *ReviewRepositoryImpl) put(review *proto.Review) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.reviews[review.Id] != nil {
+		r.delete(review.Id)
+	}
+	r.insert(review)
+}
+
+// remove deletes a review if it exists.
+func (r *ReviewRepositoryImpl) remove(id string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.reviews[id] != nil {
+		r.delete(id)
+	}
+}
+
+// snapshot returns a copy of the stored review, or nil if there is none.
+func (r *ReviewRepositoryImpl) snapshot(id string) *proto.Review {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	review := r.reviews[id]
+	if review == nil {
+		return nil
+	}
+	return cloneReview(review)
+}
+
+// each calls fn with every review under the read lock.
+func (r *ReviewRepositoryImpl) each(fn func(review *proto.Review) error) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, review := range r.reviews {
+		err := fn(review)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insert adds a review, the caller must hold the lock.
+func (r *ReviewRepositoryImpl) insert(review *proto.Review) {
+	authors := r.authors[review.LaptopId]
+	if authors == nil {
+		authors = make(map[string]string)
+		r.authors[review.LaptopId] = authors
+	}
+
+	authors[review.Author] = review.Id
+	r.reviews[review.Id] = review
+}
+
+// delete removes a stored review, the caller must hold the lock.
+func (r *ReviewRepositoryImpl) delete(id string) {
+	review := r.reviews[id]
+	delete(r.reviews, id)
+
+	authors := r.authors[review.LaptopId]
+	delete(authors, review.Author)
+	if len(authors) == 0 {
+		delete(r.authors, review.LaptopId)
+	}
+}
+
+func cloneReview(review *proto.Review) *proto.Review {
+	return protobuf.Clone(review).(*proto.Review)
+}
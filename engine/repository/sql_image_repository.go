@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// SQLImageRepository writes images to a folder like ImageRepositoryImpl and
+// keeps their metadata in a relational database.
+type SQLImageRepository struct {
+	db          *sql.DB
+	dialect     SQLDialect
+	imageFolder string
+}
+
+// NewSQLImageRepository returns a repository on a database migrated with
+// MigrateSQL.
+func NewSQLImageRepository(db *sql.DB, dialect SQLDialect, imageFolder string) *SQLImageRepository {
+	return &SQLImageRepository{db: db, dialect: dialect, imageFolder: imageFolder}
+}
+
+func (r *SQLImageRepository) Save(laptopID, imageType string, imageData bytes.Buffer) (string, error) {
+	imageID, imagePath, err := writeImage(r.imageFolder, imageType, imageData)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = r.db.Exec(r.dialect.rebind(`INSERT INTO images (id, laptop_id, type, path) VALUES (?, ?, ?, ?)`),
+		imageID, laptopID, imageType, imagePath)
+	if err != nil {
+		os.Remove(imagePath)
+		return "", fmt.Errorf("cannot insert image: %w", err)
+	}
+
+	return imageID, nil
+}
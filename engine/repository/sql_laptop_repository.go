@@ -0,0 +1,468 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"strings"
+)
+
+// SQLLaptopRepository stores laptops in a relational database. The columns
+// hold what a Filter can constrain, the full laptop is kept as a protobuf
+// blob. Filters become WHERE clauses, queries, text search and ordering are
+// evaluated on the selected rows.
+type SQLLaptopRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLLaptopRepository returns a repository on a database migrated with
+// MigrateSQL.
+func NewSQLLaptopRepository(db *sql.DB, dialect SQLDialect) *SQLLaptopRepository {
+	return &SQLLaptopRepository{db: db, dialect: dialect}
+}
+
+const laptopColumns = `brand, name, price_usd, release_year, cpu_cores, cpu_ghz, ram_bits, ssd_bits, hdd_bits,
+	screen_inch, screen_width, screen_height, screen_panel, multitouch, keyboard_layout, keyboard_backlit,
+	weight_kg, version, data`
+
+// laptopValues returns the values of laptopColumns for a laptop.
+func laptopValues(laptop *proto.Laptop) ([]interface{}, error) {
+	data, err := protobuf.Marshal(laptop)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal laptop: %w", err)
+	}
+
+	var weight sql.NullFloat64
+	weight.Float64, weight.Valid = weightKg(laptop)
+
+	screen := laptop.GetScreen()
+	return []interface{}{
+		laptop.GetBrand(),
+		laptop.GetName(),
+		laptop.GetPriceUsd(),
+		int64(laptop.GetReleaseYear()),
+		int64(laptop.GetCpu().GetNumberCores()),
+		laptop.GetCpu().GetMinGhz(),
+		int64(toBit(laptop.GetRam())),
+		int64(storageCapacity(laptop, proto.Storage_SSD)),
+		int64(storageCapacity(laptop, proto.Storage_HDD)),
+		float64(screen.GetSizeInch()),
+		int64(screen.GetResolution().GetWidth()),
+		int64(screen.GetResolution().GetHeight()),
+		int64(screen.GetPanel()),
+		screen.GetMultitouch(),
+		int64(laptop.GetKeyboard().GetLayout()),
+		laptop.GetKeyboard().GetBacklit(),
+		weight,
+		int64(laptop.GetVersion()),
+		data,
+	}, nil
+}
+
+func (r *SQLLaptopRepository) Save(laptop *proto.Laptop) error {
+	ctx := context.Background()
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := r.version(ctx, tx, laptop.GetId())
+		if err == nil {
+			return ErrAlreadyExists
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		other := clone(laptop)
+		other.Version = 1
+		other.UpdatedAt = timestamppb.Now()
+
+		values, err := laptopValues(other)
+		if err != nil {
+			return err
+		}
+
+		placeholders := strings.Repeat(", ?", len(values))
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO laptops (id, `+laptopColumns+`) VALUES (?`+placeholders+`)`),
+			append([]interface{}{other.GetId()}, values...)...)
+		if err != nil {
+			return fmt.Errorf("cannot insert laptop: %w", err)
+		}
+
+		return r.insertGPUs(ctx, tx, other)
+	})
+}
+
+// version returns the stored version of a laptop or ErrNotFound.
+func (r *SQLLaptopRepository) version(ctx context.Context, tx *sql.Tx, id string) (uint64, error) {
+	var version int64
+	err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT version FROM laptops WHERE id = ?`), id).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cannot find laptop: %w", err)
+	}
+	return uint64(version), nil
+}
+
+func (r *SQLLaptopRepository) insertGPUs(ctx context.Context, tx *sql.Tx, laptop *proto.Laptop) error {
+	for _, gpu := range laptop.GetGpus() {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO laptop_gpus (laptop_id, brand, memory_bits) VALUES (?, ?, ?)`),
+			laptop.GetId(), gpu.GetBrand(), int64(toBit(gpu.GetMemory())))
+		if err != nil {
+			return fmt.Errorf("cannot insert laptop GPU: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *SQLLaptopRepository) deleteGPUs(ctx context.Context, tx *sql.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM laptop_gpus WHERE laptop_id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("cannot delete laptop GPUs: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLLaptopRepository) Find(id string) (*proto.Laptop, error) {
+	row := r.db.QueryRow(r.dialect.rebind(`SELECT data FROM laptops WHERE id = ?`), id)
+
+	laptop, err := scanLaptop(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return laptop, err
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLaptop(row rowScanner) (*proto.Laptop, error) {
+	var data []byte
+	err := row.Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	laptop := &proto.Laptop{}
+	err = protobuf.Unmarshal(data, laptop)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal laptop: %w", err)
+	}
+	return laptop, nil
+}
+
+// Update replaces the stored laptop. A non-zero laptop.Version must match the
+// stored version, otherwise ErrVersionMismatch is returned.
+func (r *SQLLaptopRepository) Update(laptop *proto.Laptop) (*proto.Laptop, error) {
+	ctx := context.Background()
+	other := clone(laptop)
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		version, err := r.version(ctx, tx, laptop.GetId())
+		if err != nil {
+			return err
+		}
+
+		if laptop.Version != 0 && laptop.Version != version {
+			return ErrVersionMismatch
+		}
+
+		other.Version = version + 1
+		other.UpdatedAt = timestamppb.Now()
+
+		values, err := laptopValues(other)
+		if err != nil {
+			return err
+		}
+
+		columns := strings.Split(laptopColumns, ",")
+		for i, column := range columns {
+			columns[i] = strings.TrimSpace(column) + " = ?"
+		}
+
+		// the version condition guards against a concurrent update
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE laptops SET `+strings.Join(columns, ", ")+` WHERE id = ? AND version = ?`),
+			append(values, other.GetId(), int64(version))...)
+		if err != nil {
+			return fmt.Errorf("cannot update laptop: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrVersionMismatch
+		}
+
+		err = r.deleteGPUs(ctx, tx, other.GetId())
+		if err != nil {
+			return err
+		}
+		return r.insertGPUs(ctx, tx, other)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return other, nil
+}
+
+// Delete removes the laptop. A non-zero version must match the stored
+// version, otherwise ErrVersionMismatch is returned.
+func (r *SQLLaptopRepository) Delete(id string, version uint64) error {
+	ctx := context.Background()
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		current, err := r.version(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if version != 0 && version != current {
+			return ErrVersionMismatch
+		}
+
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM laptops WHERE id = ? AND version = ?`), id, int64(current))
+		if err != nil {
+			return fmt.Errorf("cannot delete laptop: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return ErrVersionMismatch
+		}
+
+		// the foreign key cascades only where it is enforced
+		return r.deleteGPUs(ctx, tx, id)
+	})
+}
+
+func (r *SQLLaptopRepository) Search(ctx context.Context, filter *proto.Filter, options SearchOptions, found func(laptop *proto.Laptop) error) error {
+	laptops, err := r.query(ctx, filter, options.Query)
+	if err != nil {
+		return err
+	}
+
+	relevance := textRelevance(laptops, options.Text)
+	laptops, err = selectLaptops(options, relevance, func(found func(laptop *proto.Laptop)) error {
+		for _, laptop := range laptops {
+			if _, ok := relevance[laptop.GetId()]; relevance == nil || ok {
+				found(laptop)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, laptop := range laptops {
+		err := found(laptop)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// textRelevance indexes the laptops to score them for a text search, it
+// returns nil if the text has no words.
+func textRelevance(laptops []*proto.Laptop, text string) map[string]float64 {
+	if len(tokenize(text)) == 0 {
+		return nil
+	}
+
+	index := newTextIndex()
+	for _, laptop := range laptops {
+		index.add(laptop)
+	}
+	return index.search(text)
+}
+
+func (r *SQLLaptopRepository) Facets(ctx context.Context, filter *proto.Filter, options SearchOptions) (*Facets, error) {
+	laptops, err := r.query(ctx, filter, options.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	relevance := textRelevance(laptops, options.Text)
+	facets := newFacets()
+	for _, laptop := range laptops {
+		if _, ok := relevance[laptop.GetId()]; relevance == nil || ok {
+			facets.add(laptop)
+		}
+	}
+
+	return facets, nil
+}
+
+func (r *SQLLaptopRepository) List(ctx context.Context, found func(laptop *proto.Laptop) error) error {
+	laptops, err := r.query(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, laptop := range laptops {
+		err := found(laptop)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// query returns the laptops selected by the WHERE clause of the filter that
+// also satisfy the query. The filter is checked again on the loaded laptops,
+// so a database that compares more loosely cannot add laptops to the result.
+func (r *SQLLaptopRepository) query(ctx context.Context, filter *proto.Filter, query Query) ([]*proto.Laptop, error) {
+	where, args := filterWhere(filter)
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`SELECT data FROM laptops WHERE `+where), args...)
+	if err != nil {
+		return nil, contextOr(ctx, fmt.Errorf("cannot query laptops: %w", err))
+	}
+	defer rows.Close()
+
+	var laptops []*proto.Laptop
+	for rows.Next() {
+		if err := contextError(ctx); err != nil {
+			return nil, err
+		}
+
+		laptop, err := scanLaptop(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		if IsQualified(filter, laptop) && (query == nil || query.Match(laptop)) {
+			laptops = append(laptops, laptop)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, contextOr(ctx, fmt.Errorf("cannot read laptops: %w", err))
+	}
+	return laptops, nil
+}
+
+// contextOr returns the status error of a done context, or err otherwise.
+func contextOr(ctx context.Context, err error) error {
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// filterWhere translates a filter into a WHERE condition with ? placeholders
+// and its arguments.
+func filterWhere(filter *proto.Filter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, values ...interface{}) {
+		conditions = append(conditions, condition)
+		args = append(args, values...)
+	}
+	in := func(column string, values []interface{}) {
+		add(column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")", values...)
+	}
+
+	if filter == nil {
+		return "1 = 1", nil
+	}
+
+	if filter.MaxPriceUsd != nil {
+		add("price_usd <= ?", filter.GetMaxPriceUsd())
+	}
+	if filter.MinPriceUsd != nil {
+		add("price_usd >= ?", filter.GetMinPriceUsd())
+	}
+	if filter.MinCpuCores != nil {
+		add("cpu_cores >= ?", int64(filter.GetMinCpuCores()))
+	}
+	if filter.MinCpuGhz != nil {
+		add("cpu_ghz >= ?", filter.GetMinCpuGhz())
+	}
+	if filter.GetMinRam() != nil {
+		add("ram_bits >= ?", int64(toBit(filter.GetMinRam())))
+	}
+	if len(filter.GetBrands()) > 0 {
+		in("LOWER(brand)", lowerValues(filter.GetBrands()))
+	}
+	if len(filter.GetNames()) > 0 {
+		in("LOWER(name)", lowerValues(filter.GetNames()))
+	}
+
+	if len(filter.GetGpuBrands()) > 0 || filter.GetMinGpuMemory() != nil {
+		// brand and memory must hold for the same GPU
+		gpu := []string{"g.laptop_id = laptops.id", "g.memory_bits >= ?"}
+		gpuArgs := []interface{}{int64(toBit(filter.GetMinGpuMemory()))}
+		if brands := filter.GetGpuBrands(); len(brands) > 0 {
+			gpu = append(gpu, "LOWER(g.brand) IN (?"+strings.Repeat(", ?", len(brands)-1)+")")
+			gpuArgs = append(gpuArgs, lowerValues(brands)...)
+		}
+		add("EXISTS (SELECT 1 FROM laptop_gpus g WHERE "+strings.Join(gpu, " AND ")+")", gpuArgs...)
+	}
+
+	if filter.GetMinSsdCapacity() != nil {
+		add("ssd_bits >= ?", int64(toBit(filter.GetMinSsdCapacity())))
+	}
+	if filter.GetMinHddCapacity() != nil {
+		add("hdd_bits >= ?", int64(toBit(filter.GetMinHddCapacity())))
+	}
+	if filter.MinScreenInch != nil {
+		add("screen_inch >= ?", float64(filter.GetMinScreenInch()))
+	}
+	if filter.MaxScreenInch != nil {
+		add("screen_inch <= ?", float64(filter.GetMaxScreenInch()))
+	}
+	if resolution := filter.GetMinResolution(); resolution != nil {
+		add("screen_width >= ? AND screen_height >= ?", int64(resolution.GetWidth()), int64(resolution.GetHeight()))
+	}
+	if len(filter.GetPanels()) > 0 {
+		in("screen_panel", enumValues(filter.GetPanels()))
+	}
+	if filter.Multitouch != nil {
+		add("multitouch = ?", filter.GetMultitouch())
+	}
+	if len(filter.GetKeyboardLayouts()) > 0 {
+		in("keyboard_layout", enumValues(filter.GetKeyboardLayouts()))
+	}
+	if filter.KeyboardBacklit != nil {
+		add("keyboard_backlit = ?", filter.GetKeyboardBacklit())
+	}
+	if filter.MinReleaseYear != nil {
+		add("release_year >= ?", int64(filter.GetMinReleaseYear()))
+	}
+	if filter.MaxReleaseYear != nil {
+		add("release_year <= ?", int64(filter.GetMaxReleaseYear()))
+	}
+
+	switch weight := filter.GetMaxWeight().(type) {
+	case *proto.Filter_MaxWeightKg:
+		add("weight_kg <= ?", weight.MaxWeightKg)
+	case *proto.Filter_MaxWeightLb:
+		add("weight_kg <= ?", weight.MaxWeightLb*kgPerLb)
+	}
+
+	if len(conditions) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func lowerValues(values []string) []interface{} {
+	lower := make([]interface{}, len(values))
+	for i, value := range values {
+		lower[i] = strings.ToLower(value)
+	}
+	return lower
+}
+
+func enumValues[T ~int32](values []T) []interface{} {
+	numbers := make([]interface{}, len(values))
+	for i, value := range values {
+		numbers[i] = int64(value)
+	}
+	return numbers
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"testing"
+)
+
+func TestFilterWhere(t *testing.T) {
+	t.Parallel()
+
+	maxPrice := 2000.0
+	backlit := true
+	testCases := []struct {
+		name   string
+		filter *proto.Filter
+		where  string
+		args   []interface{}
+	}{
+		{"nil_filter", nil, "1 = 1", nil},
+		{"empty_filter", &proto.Filter{}, "1 = 1", nil},
+		{
+			"price_and_brands",
+			&proto.Filter{MaxPriceUsd: &maxPrice, Brands: []string{"Dell", "Apple"}},
+			"price_usd <= ? AND LOWER(brand) IN (?, ?)",
+			[]interface{}{2000.0, "dell", "apple"},
+		},
+		{
+			"gpu_brand_and_memory",
+			&proto.Filter{GpuBrands: []string{"NVIDIA"}, MinGpuMemory: &proto.Memory{Value: 1, Unit: proto.Memory_BYTE}},
+			"EXISTS (SELECT 1 FROM laptop_gpus g WHERE g.laptop_id = laptops.id AND g.memory_bits >= ? AND LOWER(g.brand) IN (?))",
+			[]interface{}{int64(8), "nvidia"},
+		},
+		{
+			"panels_backlit_and_weight",
+			&proto.Filter{
+				Panels:          []proto.Screen_Panel{proto.Screen_IPS, proto.Screen_OLED},
+				KeyboardBacklit: &backlit,
+				MaxWeight:       &proto.Filter_MaxWeightKg{MaxWeightKg: 2},
+			},
+			"screen_panel IN (?, ?) AND keyboard_backlit = ? AND weight_kg <= ?",
+			[]interface{}{int64(proto.Screen_IPS), int64(proto.Screen_OLED), true, 2.0},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			where, args := filterWhere(tc.filter)
+			require.Equal(t, tc.where, where)
+			require.Equal(t, tc.args, args)
+		})
+	}
+}
+
+func TestSQLDialectRebind(t *testing.T) {
+	t.Parallel()
+
+	query := "SELECT data FROM laptops WHERE id = ? AND version = ?"
+	require.Equal(t, query, SQLite.rebind(query))
+	require.Equal(t, "SELECT data FROM laptops WHERE id = $1 AND version = $2", Postgres.rebind(query))
+}
+
+func TestParseSQLDialect(t *testing.T) {
+	t.Parallel()
+
+	for _, driver := range []string{"sqlite", "sqlite3"} {
+		dialect, err := ParseSQLDialect(driver)
+		require.NoError(t, err)
+		require.Equal(t, SQLite, dialect)
+	}
+
+	// only drivers linked into the server are accepted
+	for _, driver := range []string{"postgres", "pgx", "mysql"} {
+		_, err := ParseSQLDialect(driver)
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// SQLRatingRepository stores the scores of the users in a relational database
+// and maintains the rating of every laptop next to them.
+type SQLRatingRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+
+	mutex sync.RWMutex
+	prior RatingPrior
+}
+
+// NewSQLRatingRepository returns a repository on a database migrated with
+// MigrateSQL.
+func NewSQLRatingRepository(db *sql.DB, dialect SQLDialect) *SQLRatingRepository {
+	return &SQLRatingRepository{db: db, dialect: dialect, prior: DefaultRatingPrior}
+}
+
+func (r *SQLRatingRepository) Rate(laptopID, username string, score float64) (*Rating, error) {
+	err := checkScore(score)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	var rating *Rating
+
+	err = inTx(ctx, r.db, func(tx *sql.Tx) error {
+		previous, replaced, err := r.score(ctx, tx, laptopID, username)
+		if err != nil {
+			return err
+		}
+
+		if replaced {
+			_, err = tx.ExecContext(ctx, r.dialect.rebind(`UPDATE user_ratings SET score = ? WHERE laptop_id = ? AND username = ?`),
+				score, laptopID, username)
+			if err != nil {
+				return fmt.Errorf("cannot update score: %w", err)
+			}
+
+			_, err = tx.ExecContext(ctx, r.dialect.rebind(`UPDATE ratings SET sum = sum - ? + ? WHERE laptop_id = ?`),
+				previous, score, laptopID)
+			if err != nil {
+				return fmt.Errorf("cannot update rating: %w", err)
+			}
+
+			err = r.removeFromHistogram(ctx, tx, laptopID, previous)
+			if err != nil {
+				return err
+			}
+		} else {
+			_, err = tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO user_ratings (laptop_id, username, score) VALUES (?, ?, ?)`),
+				laptopID, username, score)
+			if err != nil {
+				return fmt.Errorf("cannot insert score: %w", err)
+			}
+
+			// a concurrent first rating makes the insert fail rather than
+			// lose a score, the other updates are atomic in the database
+			result, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE ratings SET count = count + 1, sum = sum + ? WHERE laptop_id = ?`),
+				score, laptopID)
+			if err != nil {
+				return fmt.Errorf("cannot update rating: %w", err)
+			}
+
+			err = insertIfMissing(result, func() error {
+				_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO ratings (laptop_id, count, sum, min_score, max_score) VALUES (?, 1, ?, ?, ?)`),
+					laptopID, score, score, score)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("cannot insert rating: %w", err)
+			}
+		}
+
+		err = r.addToHistogram(ctx, tx, laptopID, score)
+		if err != nil {
+			return err
+		}
+
+		rating, err = r.refresh(ctx, tx, laptopID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rating, nil
+}
+
+func (r *SQLRatingRepository) Retract(laptopID, username string) (*Rating, error) {
+	ctx := context.Background()
+	var rating *Rating
+
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		score, rated, err := r.score(ctx, tx, laptopID, username)
+		if err != nil {
+			return err
+		}
+		if !rated {
+			return ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM user_ratings WHERE laptop_id = ? AND username = ?`),
+			laptopID, username)
+		if err != nil {
+			return fmt.Errorf("cannot delete score: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`UPDATE ratings SET count = count - 1, sum = sum - ? WHERE laptop_id = ?`),
+			score, laptopID)
+		if err != nil {
+			return fmt.Errorf("cannot update rating: %w", err)
+		}
+
+		err = r.removeFromHistogram(ctx, tx, laptopID, score)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM ratings WHERE laptop_id = ? AND count = 0`), laptopID)
+		if err != nil {
+			return fmt.Errorf("cannot delete rating: %w", err)
+		}
+
+		rating, err = r.refresh(ctx, tx, laptopID)
+		if errors.Is(err, ErrNotFound) {
+			rating, err = &Rating{}, nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rating, nil
+}
+
+func (r *SQLRatingRepository) Find(laptopID string) (*Rating, error) {
+	return r.find(context.Background(), r.db, laptopID)
+}
+
+func (r *SQLRatingRepository) FindAll(laptopIDs []string) (map[string]*Rating, error) {
+	ratings := make(map[string]*Rating)
+	for _, laptopID := range laptopIDs {
+		rating, err := r.Find(laptopID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ratings[laptopID] = rating
+	}
+
+	return ratings, nil
+}
+
+// topRatedPage is the number of ranked laptops read at once by TopRated.
+const topRatedPage = 50
+
+func (r *SQLRatingRepository) TopRated(limit int, accept func(laptopID string) (bool, error)) ([]*RankedRating, error) {
+	ctx := context.Background()
+	var top []*RankedRating
+	var last *RankedRating
+
+	for {
+		page, err := r.rankingPage(ctx, last)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ranked := range page {
+			if limit > 0 && len(top) >= limit {
+				return top, nil
+			}
+
+			accepted, err := accept(ranked.LaptopID)
+			if err != nil {
+				return nil, err
+			}
+			if !accepted {
+				continue
+			}
+
+			ranked.Rating, err = r.Find(ranked.LaptopID)
+			if errors.Is(err, ErrNotFound) {
+				// retracted since the page was read
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			top = append(top, ranked)
+		}
+
+		if len(page) < topRatedPage || (limit > 0 && len(top) >= limit) {
+			return top, nil
+		}
+		last = page[len(page)-1]
+	}
+}
+
+// rankingPage returns the laptops ranked after the last one, the rows are
+// read before accept is called as it may query the same database.
+func (r *SQLRatingRepository) rankingPage(ctx context.Context, last *RankedRating) ([]*RankedRating, error) {
+	query := `SELECT laptop_id, bayesian_score FROM ratings`
+	var args []interface{}
+	if last != nil {
+		query += ` WHERE bayesian_score < ? OR (bayesian_score = ? AND laptop_id > ?)`
+		args = append(args, last.Score, last.Score, last.LaptopID)
+	}
+	query += ` ORDER BY bayesian_score DESC, laptop_id LIMIT ?`
+	args = append(args, topRatedPage)
+
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot rank ratings: %w", err)
+	}
+	defer rows.Close()
+
+	var page []*RankedRating
+	for rows.Next() {
+		ranked := &RankedRating{}
+		err := rows.Scan(&ranked.LaptopID, &ranked.Score)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan ranked rating: %w", err)
+		}
+		page = append(page, ranked)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot rank ratings: %w", err)
+	}
+
+	return page, nil
+}
+
+// SetPrior stores the rank of every laptop with the prior, which the
+// following ratings are ranked with too.
+func (r *SQLRatingRepository) SetPrior(prior RatingPrior) error {
+	err := prior.Validate()
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, err = r.db.ExecContext(context.Background(), r.dialect.rebind(`UPDATE ratings SET bayesian_score = (? + sum) / (? + count)`),
+		prior.Weight*prior.Mean, prior.Weight)
+	if err != nil {
+		return fmt.Errorf("cannot rank ratings: %w", err)
+	}
+
+	r.prior = prior
+	return nil
+}
+
+func (r *SQLRatingRepository) currentPrior() RatingPrior {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.prior
+}
+
+// score returns the score of a user for a laptop.
+func (r *SQLRatingRepository) score(ctx context.Context, tx *sql.Tx, laptopID, username string) (float64, bool, error) {
+	var score float64
+	err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT score FROM user_ratings WHERE laptop_id = ? AND username = ?`),
+		laptopID, username).Scan(&score)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("cannot find score: %w", err)
+	}
+	return score, true, nil
+}
+
+func (r *SQLRatingRepository) addToHistogram(ctx context.Context, tx *sql.Tx, laptopID string, score float64) error {
+	bucket := scoreBucket(score)
+	result, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE rating_histogram SET count = count + 1 WHERE laptop_id = ? AND bucket = ?`),
+		laptopID, bucket)
+	if err != nil {
+		return fmt.Errorf("cannot update rating histogram: %w", err)
+	}
+
+	err = insertIfMissing(result, func() error {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO rating_histogram (laptop_id, bucket, count) VALUES (?, ?, 1)`),
+			laptopID, bucket)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("cannot insert rating histogram: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLRatingRepository) removeFromHistogram(ctx context.Context, tx *sql.Tx, laptopID string, score float64) error {
+	bucket := scoreBucket(score)
+	_, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE rating_histogram SET count = count - 1 WHERE laptop_id = ? AND bucket = ?`),
+		laptopID, bucket)
+	if err != nil {
+		return fmt.Errorf("cannot update rating histogram: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM rating_histogram WHERE laptop_id = ? AND bucket = ? AND count = 0`),
+		laptopID, bucket)
+	if err != nil {
+		return fmt.Errorf("cannot delete rating histogram: %w", err)
+	}
+	return nil
+}
+
+// refresh recomputes the lowest and the highest score of a laptop from the
+// scores of its users and its rank, and returns the new rating.
+func (r *SQLRatingRepository) refresh(ctx context.Context, tx *sql.Tx, laptopID string) (*Rating, error) {
+	prior := r.currentPrior()
+	_, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE ratings SET
+		min_score = (SELECT MIN(score) FROM user_ratings WHERE laptop_id = ?),
+		max_score = (SELECT MAX(score) FROM user_ratings WHERE laptop_id = ?),
+		bayesian_score = (? + sum) / (? + count)
+		WHERE laptop_id = ?`),
+		laptopID, laptopID, prior.Weight*prior.Mean, prior.Weight, laptopID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot update rating: %w", err)
+	}
+
+	return r.find(ctx, tx, laptopID)
+}
+
+func (r *SQLRatingRepository) find(ctx context.Context, db sqlQuerier, laptopID string) (*Rating, error) {
+	rating := &Rating{}
+	err := db.QueryRowContext(ctx, r.dialect.rebind(`SELECT count, sum, min_score, max_score FROM ratings WHERE laptop_id = ?`), laptopID).
+		Scan(&rating.Count, &rating.Sum, &rating.Min, &rating.Max)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot find rating: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, r.dialect.rebind(`SELECT bucket, count FROM rating_histogram WHERE laptop_id = ?`), laptopID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find rating histogram: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bucket int
+		var count uint32
+		err := rows.Scan(&bucket, &count)
+		if err != nil {
+			return nil, fmt.Errorf("cannot scan rating histogram: %w", err)
+		}
+
+		if rating.Histogram == nil {
+			rating.Histogram = make(map[int]uint32)
+		}
+		rating.Histogram[bucket] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot find rating histogram: %w", err)
+	}
+
+	return rating, nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SQLDialect adapts the statements of the SQL repositories to a database.
+type SQLDialect int
+
+const (
+	SQLite SQLDialect = iota
+	// Postgres uses $1, $2, ... placeholders and BYTEA for binary data. Its
+	// driver is not linked in, ParseSQLDialect does not return it.
+	Postgres
+)
+
+// ParseSQLDialect returns the dialect for the name of a linked database/sql
+// driver.
+func ParseSQLDialect(driver string) (SQLDialect, error) {
+	switch driver {
+	case "sqlite", "sqlite3":
+		return SQLite, nil
+	default:
+		return 0, fmt.Errorf("unsupported SQL driver %q", driver)
+	}
+}
+
+// rebind rewrites the ? placeholders of a statement for the dialect.
+func (d SQLDialect) rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+
+	var builder strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			builder.WriteRune(r)
+			continue
+		}
+		n++
+		builder.WriteString("$" + strconv.Itoa(n))
+	}
+	return builder.String()
+}
+
+func (d SQLDialect) blobType() string {
+	if d == Postgres {
+		return "BYTEA"
+	}
+	return "BLOB"
+}
+
+// sqlMigrations are applied in order, each one once. Applied migrations must
+// never change, new schema changes go into new migrations.
+var sqlMigrations = []func(d SQLDialect) []string{
+	func(d SQLDialect) []string {
+		return []string{
+			`CREATE TABLE laptops (
+				id VARCHAR(255) PRIMARY KEY,
+				brand VARCHAR(255) NOT NULL,
+				name VARCHAR(255) NOT NULL,
+				price_usd DOUBLE PRECISION NOT NULL,
+				release_year BIGINT NOT NULL,
+				cpu_cores BIGINT NOT NULL,
+				cpu_ghz DOUBLE PRECISION NOT NULL,
+				ram_bits BIGINT NOT NULL,
+				ssd_bits BIGINT NOT NULL,
+				hdd_bits BIGINT NOT NULL,
+				screen_inch DOUBLE PRECISION NOT NULL,
+				screen_width BIGINT NOT NULL,
+				screen_height BIGINT NOT NULL,
+				screen_panel BIGINT NOT NULL,
+				multitouch BOOLEAN NOT NULL,
+				keyboard_layout BIGINT NOT NULL,
+				keyboard_backlit BOOLEAN NOT NULL,
+				weight_kg DOUBLE PRECISION,
+				version BIGINT NOT NULL,
+				data ` + d.blobType() + ` NOT NULL
+			)`,
+			`CREATE INDEX laptops_price_usd ON laptops (price_usd)`,
+			`CREATE INDEX laptops_release_year ON laptops (release_year)`,
+			`CREATE TABLE laptop_gpus (
+				laptop_id VARCHAR(255) NOT NULL REFERENCES laptops (id) ON DELETE CASCADE,
+				brand VARCHAR(255) NOT NULL,
+				memory_bits BIGINT NOT NULL
+			)`,
+			`CREATE INDEX laptop_gpus_laptop_id ON laptop_gpus (laptop_id)`,
+			`CREATE TABLE users (
+				username VARCHAR(255) PRIMARY KEY,
+				hashed_password VARCHAR(255) NOT NULL,
+				role VARCHAR(255) NOT NULL
+			)`,
+			`CREATE TABLE ratings (
+				laptop_id VARCHAR(255) PRIMARY KEY,
+				count BIGINT NOT NULL,
+				sum DOUBLE PRECISION NOT NULL,
+				min_score DOUBLE PRECISION NOT NULL,
+				max_score DOUBLE PRECISION NOT NULL,
+				bayesian_score DOUBLE PRECISION NOT NULL DEFAULT 0
+			)`,
+			`CREATE INDEX ratings_bayesian_score ON ratings (bayesian_score DESC, laptop_id)`,
+			`CREATE TABLE rating_histogram (
+				laptop_id VARCHAR(255) NOT NULL,
+				bucket BIGINT NOT NULL,
+				count BIGINT NOT NULL,
+				PRIMARY KEY (laptop_id, bucket)
+			)`,
+			`CREATE TABLE user_ratings (
+				laptop_id VARCHAR(255) NOT NULL,
+				username VARCHAR(255) NOT NULL,
+				score DOUBLE PRECISION NOT NULL,
+				PRIMARY KEY (laptop_id, username)
+			)`,
+			`CREATE TABLE images (
+				id VARCHAR(255) PRIMARY KEY,
+				laptop_id VARCHAR(255) NOT NULL,
+				type VARCHAR(255) NOT NULL,
+				path VARCHAR(1024) NOT NULL
+			)`,
+			`CREATE TABLE reviews (
+				id VARCHAR(255) PRIMARY KEY,
+				laptop_id VARCHAR(255) NOT NULL,
+				author VARCHAR(255) NOT NULL,
+				created_at BIGINT NOT NULL,
+				hidden BOOLEAN NOT NULL,
+				data ` + d.blobType() + ` NOT NULL,
+				UNIQUE (laptop_id, author)
+			)`,
+			`CREATE INDEX reviews_laptop_created_at ON reviews (laptop_id, created_at DESC, id)`,
+		}
+	},
+}
+
+// MigrateSQL brings the schema of the SQL repositories up to date. Every
+// migration runs in its own transaction and is recorded in
+// schema_migrations.
+func MigrateSQL(ctx context.Context, db *sql.DB, dialect SQLDialect) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("cannot create migration table: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("cannot read schema version: %w", err)
+	}
+
+	for version := current + 1; version <= len(sqlMigrations); version++ {
+		err := inTx(ctx, db, func(tx *sql.Tx) error {
+			for _, statement := range sqlMigrations[version-1](dialect) {
+				_, err := tx.ExecContext(ctx, statement)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err := tx.ExecContext(ctx, dialect.rebind(`INSERT INTO schema_migrations (version) VALUES (?)`), version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("cannot apply migration %d: %w", version, err)
+		}
+	}
+
+	return nil
+}
+
+// inTx runs fn in a transaction, which is committed if fn succeeds and rolled
+// back otherwise.
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sqlQuerier is implemented by both *sql.DB and *sql.Tx.
+type sqlQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// insertIfMissing calls insert if an update found no row.
+func insertIfMissing(result sql.Result, insert func() error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+	return insert()
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// SQLReviewRepository stores reviews in a relational database. The columns
+// hold what a list selects and orders by, the full review is kept as a
+// protobuf blob.
+type SQLReviewRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLReviewRepository returns a repository on a database migrated with
+// MigrateSQL.
+func NewSQLReviewRepository(db *sql.DB, dialect SQLDialect) *SQLReviewRepository {
+	return &SQLReviewRepository{db: db, dialect: dialect}
+}
+
+func (r *SQLReviewRepository) Save(review *proto.Review) error {
+	data, err := protobuf.Marshal(review)
+	if err != nil {
+		return fmt.Errorf("cannot marshal review: %w", err)
+	}
+
+	ctx := context.Background()
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var count int
+		err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM reviews WHERE id = ? OR (laptop_id = ? AND author = ?)`),
+			review.GetId(), review.GetLaptopId(), review.GetAuthor()).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("cannot find review: %w", err)
+		}
+		if count > 0 {
+			return ErrAlreadyExists
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO reviews (id, laptop_id, author, created_at, hidden, data) VALUES (?, ?, ?, ?, ?, ?)`),
+			review.GetId(), review.GetLaptopId(), review.GetAuthor(), review.GetCreatedAt().AsTime().UnixNano(), review.GetHidden(), data)
+		if err != nil {
+			return fmt.Errorf("cannot insert review: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *SQLReviewRepository) Find(id string) (*proto.Review, error) {
+	return r.findOne(r.db.QueryRow(r.dialect.rebind(`SELECT data FROM reviews WHERE id = ?`), id))
+}
+
+func (r *SQLReviewRepository) FindByAuthor(laptopID, author string) (*proto.Review, error) {
+	return r.findOne(r.db.QueryRow(r.dialect.rebind(`SELECT data FROM reviews WHERE laptop_id = ? AND author = ?`), laptopID, author))
+}
+
+func (r *SQLReviewRepository) findOne(row rowScanner) (*proto.Review, error) {
+	review, err := scanReview(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return review, err
+}
+
+func (r *SQLReviewRepository) Update(review *proto.Review) error {
+	ctx := context.Background()
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		current, err := scanReview(tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT data FROM reviews WHERE id = ?`), review.GetId()))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		other := cloneReview(review)
+		other.LaptopId = current.LaptopId
+		other.Author = current.Author
+		other.Hidden = current.Hidden
+		return r.write(ctx, tx, other)
+	})
+}
+
+func (r *SQLReviewRepository) SetHidden(id string, hidden bool) (*proto.Review, error) {
+	var review *proto.Review
+	ctx := context.Background()
+	err := inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var err error
+		review, err = scanReview(tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT data FROM reviews WHERE id = ?`), id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		review.Hidden = hidden
+		return r.write(ctx, tx, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// write replaces the stored review with the same ID.
+func (r *SQLReviewRepository) write(ctx context.Context, tx *sql.Tx, review *proto.Review) error {
+	data, err := protobuf.Marshal(review)
+	if err != nil {
+		return fmt.Errorf("cannot marshal review: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, r.dialect.rebind(`UPDATE reviews SET created_at = ?, hidden = ?, data = ? WHERE id = ?`),
+		review.GetCreatedAt().AsTime().UnixNano(), review.GetHidden(), data, review.GetId())
+	if err != nil {
+		return fmt.Errorf("cannot update review: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLReviewRepository) Delete(id string) error {
+	result, err := r.db.Exec(r.dialect.rebind(`DELETE FROM reviews WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("cannot delete review: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cannot delete review: %w", err)
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *SQLReviewRepository) List(laptopID string, options ReviewListOptions) ([]*proto.Review, error) {
+	query := `SELECT data FROM reviews WHERE laptop_id = ?`
+	args := []interface{}{laptopID}
+	if !options.IncludeHidden {
+		query += ` AND hidden = ?`
+		args = append(args, false)
+	}
+	if options.After != nil {
+		createdAt := options.After.CreatedAt.UnixNano()
+		query += ` AND (created_at < ? OR (created_at = ? AND id > ?))`
+		args = append(args, createdAt, createdAt, options.After.ID)
+	}
+	query += ` ORDER BY created_at DESC, id`
+	if options.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, options.Limit)
+	}
+
+	rows, err := r.db.Query(r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []*proto.Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot list reviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+func scanReview(row rowScanner) (*proto.Review, error) {
+	var data []byte
+	err := row.Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	review := &proto.Review{}
+	err = protobuf.Unmarshal(data, review)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal review: %w", err)
+	}
+	return review, nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/engine/model/entity"
+)
+
+// SQLUserRepository stores users in a relational database.
+type SQLUserRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLUserRepository returns a repository on a database migrated with
+// MigrateSQL.
+func NewSQLUserRepository(db *sql.DB, dialect SQLDialect) *SQLUserRepository {
+	return &SQLUserRepository{db: db, dialect: dialect}
+}
+
+func (r *SQLUserRepository) Save(user *entity.User) error {
+	ctx := context.Background()
+	return inTx(ctx, r.db, func(tx *sql.Tx) error {
+		var count int
+		err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM users WHERE username = ?`), user.Username).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("cannot find user: %w", err)
+		}
+		if count > 0 {
+			return ErrAlreadyExists
+		}
+
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO users (username, hashed_password, role) VALUES (?, ?, ?)`),
+			user.Username, user.HashedPassword, user.Role)
+		if err != nil {
+			return fmt.Errorf("cannot insert user: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *SQLUserRepository) Find(username string) (*entity.User, error) {
+	user := &entity.User{}
+	err := r.db.QueryRow(r.dialect.rebind(`SELECT username, hashed_password, role FROM users WHERE username = ?`), username).
+		Scan(&user.Username, &user.HashedPassword, &user.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot find user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *SQLUserRepository) Count() (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("cannot count users: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"gitlab.com/iruldev/grpc-class/proto"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Weights of the laptop fields covered by the text index. A match on the
+// brand or the model name counts more than a match on a component.
+const (
+	brandWeight     = 3
+	nameWeight      = 3
+	componentWeight = 1
+
+	// prefixFactor scales the weight of a term that only starts with the
+	// searched word, so "think" ranks "thinkpad" below an exact match.
+	prefixFactor = 0.5
+)
+
+// textIndex is an inverted index from lower-cased words of the brand, name,
+// CPU and GPU names of laptops to the laptops containing them. It is not safe
+// for concurrent use, the repository guards it with its own mutex.
+type textIndex struct {
+	// postings maps a term to the weight it has in each laptop.
+	postings map[string]map[string]float64
+	// terms holds every indexed term in sorted order for prefix lookups.
+	terms []string
+	// documents maps a laptop ID to its terms so it can be removed.
+	documents map[string][]string
+}
+
+func newTextIndex() *textIndex {
+	return &textIndex{
+		postings:  make(map[string]map[string]float64),
+		documents: make(map[string][]string),
+	}
+}
+
+// tokenize splits text into lower-cased words made of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func (x *textIndex) add(laptop *proto.Laptop) {
+	x.remove(laptop.GetId())
+
+	weights := make(map[string]float64)
+	addText := func(text string, weight float64) {
+		for _, term := range tokenize(text) {
+			weights[term] += weight
+		}
+	}
+
+	addText(laptop.GetBrand(), brandWeight)
+	addText(laptop.GetName(), nameWeight)
+	addText(laptop.GetCpu().GetBrand(), componentWeight)
+	addText(laptop.GetCpu().GetName(), componentWeight)
+	for _, gpu := range laptop.GetGpus() {
+		addText(gpu.GetBrand(), componentWeight)
+		addText(gpu.GetName(), componentWeight)
+	}
+
+	terms := make([]string, 0, len(weights))
+	for term, weight := range weights {
+		posting := x.postings[term]
+		if posting == nil {
+			posting = make(map[string]float64)
+			x.postings[term] = posting
+
+			i := sort.SearchStrings(x.terms, term)
+			x.terms = append(x.terms, "")
+			copy(x.terms[i+1:], x.terms[i:])
+			x.terms[i] = term
+		}
+		posting[laptop.GetId()] = weight
+		terms = append(terms, term)
+	}
+
+	x.documents[laptop.GetId()] = terms
+}
+
+func (x *textIndex) remove(laptopID string) {
+	for _, term := range x.documents[laptopID] {
+		posting := x.postings[term]
+		delete(posting, laptopID)
+		if len(posting) > 0 {
+			continue
+		}
+
+		delete(x.postings, term)
+		i := sort.SearchStrings(x.terms, term)
+		x.terms = append(x.terms[:i], x.terms[i+1:]...)
+	}
+	delete(x.documents, laptopID)
+}
+
+// search returns the relevance score of every laptop that contains all words
+// of the text, either exactly or as a prefix of an indexed term. It returns
+// nil if the text has no words at all.
+func (x *textIndex) search(text string) map[string]float64 {
+	var scores map[string]float64
+
+	for _, word := range tokenize(text) {
+		matches := make(map[string]float64)
+
+		i := sort.SearchStrings(x.terms, word)
+		for ; i < len(x.terms) && strings.HasPrefix(x.terms[i], word); i++ {
+			factor := prefixFactor
+			if x.terms[i] == word {
+				factor = 1
+			}
+
+			for laptopID, weight := range x.postings[x.terms[i]] {
+				if score := weight * factor; score > matches[laptopID] {
+					matches[laptopID] = score
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = matches
+			continue
+		}
+
+		for laptopID, score := range scores {
+			match, ok := matches[laptopID]
+			if !ok {
+				delete(scores, laptopID)
+				continue
+			}
+			scores[laptopID] = score + match
+		}
+	}
+
+	return scores
+}
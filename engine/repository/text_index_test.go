@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"gitlab.com/iruldev/grpc-class/sample"
+	"testing"
+)
+
+func TestLaptopRepositoryTextSearch(t *testing.T) {
+	t.Parallel()
+
+	thinkpad := sample.NewLaptop()
+	thinkpad.Brand = "Lenovo"
+	thinkpad.Name = "Thinkpad P1"
+	thinkpad.Cpu.Name = "Ryzen 7 PRO 2700U"
+	thinkpad.Gpus = []*proto.GPU{{Brand: "AMD", Name: "RX 580"}}
+	thinkpad.PriceUsd = 2000
+
+	thinkpadIntel := sample.NewLaptop()
+	thinkpadIntel.Brand = "Lenovo"
+	thinkpadIntel.Name = "Thinkpad XL"
+	thinkpadIntel.Cpu.Name = "Core i7-9750H"
+	thinkpadIntel.Gpus = []*proto.GPU{{Brand: "AMD", Name: "RX 590"}}
+	thinkpadIntel.PriceUsd = 2800
+
+	xps := sample.NewLaptop()
+	xps.Brand = "Dell"
+	xps.Name = "XPS Pro"
+	xps.Cpu.Name = "Ryzen 5 PRO 3500U"
+	xps.Gpus = []*proto.GPU{{Brand: "NVIDIA", Name: "RTX 2070"}}
+
+	repo := NewLaptopRepository()
+	for _, laptop := range []*proto.Laptop{thinkpad, thinkpadIntel, xps} {
+		require.NoError(t, repo.Save(laptop))
+	}
+
+	search := func(text string, filter *proto.Filter) []string {
+		var ids []string
+		err := repo.Search(context.Background(), filter, SearchOptions{Text: text}, func(laptop *proto.Laptop) error {
+			ids = append(ids, laptop.GetId())
+			return nil
+		})
+		require.NoError(t, err)
+		return ids
+	}
+
+	require.Equal(t, []string{thinkpad.Id}, search("thinkpad ryzen", nil))
+	require.Equal(t, []string{thinkpad.Id}, search("THINKPAD Ryz", nil))
+	require.Equal(t, []string{xps.Id}, search("rtx 2070", nil))
+	require.Empty(t, search("thinkpad rtx", nil))
+	require.ElementsMatch(t, []string{thinkpad.Id, thinkpadIntel.Id}, search("lenovo", nil))
+
+	// a match on the name ranks above a match on the CPU
+	require.Equal(t, []string{xps.Id, thinkpad.Id}, search("pro", nil))
+
+	maxPrice := 2500.0
+	require.Equal(t, []string{thinkpad.Id}, search("lenovo", &proto.Filter{MaxPriceUsd: &maxPrice}))
+
+	updated := &proto.Laptop{Id: thinkpad.Id, Brand: "Lenovo", Name: "Yoga"}
+	_, err := repo.Update(updated)
+	require.NoError(t, err)
+	require.Empty(t, search("thinkpad ryzen", nil))
+	require.Equal(t, []string{thinkpad.Id}, search("yoga", nil))
+
+	require.NoError(t, repo.Delete(xps.Id, 0))
+	require.Empty(t, search("xps", nil))
+}
@@ -8,6 +8,7 @@ import (
 type UserRepository interface {
 	Save(user *entity.User) error
 	Find(username string) (*entity.User, error)
+	Count() (int, error)
 }
 
 type UserRepositoryImpl struct {
@@ -16,6 +17,10 @@ type UserRepositoryImpl struct {
 }
 
 func NewUserRepository() UserRepository {
+	return newUserRepositoryImpl()
+}
+
+func newUserRepositoryImpl() *UserRepositoryImpl {
 	return &UserRepositoryImpl{
 		users: make(map[string]*entity.User),
 	}
@@ -39,8 +44,45 @@ func (r *UserRepositoryImpl) Find(username string) (*entity.User, error) {
 
 	user := r.users[username]
 	if user == nil {
-		return nil, nil
+		return nil, ErrNotFound
 	}
 
 	return user.Clone(), nil
 }
+
+func (r *UserRepositoryImpl) Count() (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.users), nil
+}
+
+// put stores a user as it is, replacing any user with the same username. It
+// restores users from persistent storage.
+func (r *UserRepositoryImpl) put(user *entity.User) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.users[user.Username] = user.Clone()
+}
+
+func (r *UserRepositoryImpl) remove(username string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.users, username)
+}
+
+// each calls fn with every user under the read lock.
+func (r *UserRepositoryImpl) each(fn func(user *entity.User) error) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		err := fn(user.Clone())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package repository
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyncPolicy decides when the write-ahead log is flushed to stable storage.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs the log after every write, nothing acknowledged is
+	// lost on a crash.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs the log periodically, a crash loses at most the
+	// writes of the last interval.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system.
+	SyncNever
+)
+
+func (p SyncPolicy) String() string {
+	switch p {
+	case SyncAlways:
+		return "always"
+	case SyncInterval:
+		return "interval"
+	default:
+		return "never"
+	}
+}
+
+// ParseSyncPolicy parses the name of a sync policy as returned by String.
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		if strings.EqualFold(name, policy.String()) {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sync policy %q", name)
+}
+
+const (
+	defaultSyncInterval = time.Second
+	defaultCompactAfter = 1000
+	// maxRecordSize bounds the length of a record read back, a longer length
+	// can only come from a corrupted file.
+	maxRecordSize = 16 << 20
+)
+
+// FileOptions configures the durability of the file-backed repositories.
+type FileOptions struct {
+	Sync SyncPolicy
+	// SyncInterval is the period of SyncInterval, one second if zero.
+	SyncInterval time.Duration
+	// CompactAfter is the number of log records after which the log is
+	// compacted into a snapshot, 1000 if zero.
+	CompactAfter int
+}
+
+// writeAheadLog stores the state of a repository as a snapshot file plus a
+// log of the changes made since. Both files hold records framed by their
+// length as an unsigned varint, like length-delimited protobuf messages.
+// Records must describe resulting states rather than operations, so that
+// replaying a change that is already part of the snapshot is harmless.
+type writeAheadLog struct {
+	mutex    sync.Mutex
+	path     string
+	file     *os.File
+	options  FileOptions
+	records  int
+	unsynced bool
+	// failed is set once the log may hold a record whose write was reported
+	// as failed, no further record is written then.
+	failed error
+	// syncFile flushes the log file, tests replace it to fail like a disk.
+	syncFile func(file *os.File) error
+	done     chan struct{}
+	stopped  sync.WaitGroup
+}
+
+// openWriteAheadLog replays the snapshot and the log stored under name in dir
+// and opens the log for appending. A record cut short by a crash at the end of
+// the log is discarded.
+func openWriteAheadLog(dir, name string, options FileOptions, replay func(record []byte) error) (*writeAheadLog, error) {
+	if options.SyncInterval <= 0 {
+		options.SyncInterval = defaultSyncInterval
+	}
+	if options.CompactAfter <= 0 {
+		options.CompactAfter = defaultCompactAfter
+	}
+
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create data directory: %w", err)
+	}
+
+	w := &writeAheadLog{
+		path:     filepath.Join(dir, name),
+		options:  options,
+		syncFile: (*os.File).Sync,
+		done:     make(chan struct{}),
+	}
+
+	snapshot, err := os.Open(w.snapshotPath())
+	switch {
+	case err == nil:
+		_, err = readRecords(snapshot, replay)
+		snapshot.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read snapshot %s: %w", w.snapshotPath(), err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("cannot open snapshot: %w", err)
+	}
+
+	w.file, err = os.OpenFile(w.logPath(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open log: %w", err)
+	}
+
+	count := 0
+	size, err := readRecords(w.file, func(record []byte) error {
+		count++
+		return replay(record)
+	})
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		w.file.Close()
+		return nil, fmt.Errorf("cannot read log %s: %w", w.logPath(), err)
+	}
+	if err != nil {
+		log.Printf("discard incomplete record at offset %d of %s", size, w.logPath())
+	}
+
+	err = w.file.Truncate(size)
+	if err == nil {
+		_, err = w.file.Seek(size, io.SeekStart)
+	}
+	if err != nil {
+		w.file.Close()
+		return nil, fmt.Errorf("cannot position log: %w", err)
+	}
+	w.records = count
+
+	if options.Sync == SyncInterval {
+		w.stopped.Add(1)
+		go w.syncPeriodically()
+	}
+
+	return w, nil
+}
+
+func (w *writeAheadLog) snapshotPath() string {
+	return w.path + ".snapshot"
+}
+
+func (w *writeAheadLog) logPath() string {
+	return w.path + ".wal"
+}
+
+// readRecords calls fn with every record of r and returns the number of bytes
+// of complete records. A record cut short ends the read with
+// io.ErrUnexpectedEOF, a record longer than maxRecordSize with an error.
+func readRecords(r io.Reader, fn func(record []byte) error) (int64, error) {
+	reader := bufio.NewReader(r)
+	var offset int64
+
+	for {
+		length, err := binary.ReadUvarint(reader)
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		if length > maxRecordSize {
+			return offset, fmt.Errorf("record at offset %d is corrupted, its length %d exceeds %d bytes", offset, length, maxRecordSize)
+		}
+
+		record := make([]byte, length)
+		_, err = io.ReadFull(reader, record)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return offset, err
+		}
+
+		err = fn(record)
+		if err != nil {
+			return offset, err
+		}
+		offset += int64(uvarintSize(length)) + int64(length)
+	}
+}
+
+func uvarintSize(value uint64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], value)
+}
+
+func frameRecord(record []byte) []byte {
+	frame := binary.AppendUvarint(make([]byte, 0, len(record)+binary.MaxVarintLen64), uint64(len(record)))
+	return append(frame, record...)
+}
+
+// append writes a record to the log and syncs it according to the policy. A
+// record that cannot be written or synced is removed again, so that a change
+// reported as failed is not replayed.
+func (w *writeAheadLog) append(record []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.failed != nil {
+		return w.failed
+	}
+	if len(record) > maxRecordSize {
+		return fmt.Errorf("cannot write log record of %d bytes, the limit is %d", len(record), maxRecordSize)
+	}
+
+	offset, err := w.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("cannot write log record: %w", err)
+	}
+
+	_, err = w.file.Write(frameRecord(record))
+	if err != nil {
+		// a partly written record would also hide the records after it
+		w.truncate(offset)
+		return fmt.Errorf("cannot write log record: %w", err)
+	}
+
+	if w.options.Sync != SyncAlways {
+		w.records++
+		w.unsynced = true
+		return nil
+	}
+
+	err = w.syncFile(w.file)
+	if err != nil {
+		w.truncate(offset)
+		return fmt.Errorf("cannot sync log: %w", err)
+	}
+	w.records++
+	return nil
+}
+
+// truncate removes the records from offset on. If the log cannot be cut back
+// for sure it is marked as failed. The caller must hold the mutex.
+func (w *writeAheadLog) truncate(offset int64) {
+	err := w.file.Truncate(offset)
+	if err == nil {
+		_, err = w.file.Seek(offset, io.SeekStart)
+	}
+	if err == nil && w.options.Sync == SyncAlways {
+		err = w.syncFile(w.file)
+	}
+	if err != nil {
+		w.failed = fmt.Errorf("log %s is unusable after a failed write: %w", w.logPath(), err)
+		log.Print(w.failed)
+	}
+}
+
+// needsCompaction reports whether the log has grown past the configured
+// number of records.
+func (w *writeAheadLog) needsCompaction() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.records >= w.options.CompactAfter
+}
+
+// compact replaces the snapshot with the records written by the snapshot
+// function and empties the log. The caller must keep the state from changing
+// while it runs.
+func (w *writeAheadLog) compact(snapshot func(add func(record []byte) error) error) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	temp := w.snapshotPath() + ".tmp"
+	file, err := os.Create(temp)
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	err = snapshot(func(record []byte) error {
+		_, err := writer.Write(frameRecord(record))
+		return err
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("cannot write snapshot: %w", err)
+	}
+
+	err = os.Rename(temp, w.snapshotPath())
+	if err != nil {
+		return fmt.Errorf("cannot replace snapshot: %w", err)
+	}
+	err = syncDir(filepath.Dir(w.path))
+	if err != nil {
+		return err
+	}
+
+	// the records of the log are part of the snapshot now, replaying them
+	// after a crash before the truncation is harmless
+	err = w.file.Truncate(0)
+	if err == nil {
+		_, err = w.file.Seek(0, io.SeekStart)
+	}
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot truncate log: %w", err)
+	}
+
+	w.records = 0
+	w.unsynced = false
+	return nil
+}
+
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("cannot open data directory: %w", err)
+	}
+	defer file.Close()
+
+	err = file.Sync()
+	if err != nil {
+		return fmt.Errorf("cannot sync data directory: %w", err)
+	}
+	return nil
+}
+
+func (w *writeAheadLog) sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.unsynced {
+		return nil
+	}
+
+	err := w.syncFile(w.file)
+	if err != nil {
+		return fmt.Errorf("cannot sync log: %w", err)
+	}
+	w.unsynced = false
+	return nil
+}
+
+func (w *writeAheadLog) syncPeriodically() {
+	defer w.stopped.Done()
+
+	ticker := time.NewTicker(w.options.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			err := w.sync()
+			if err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// close syncs and closes the log.
+func (w *writeAheadLog) close() error {
+	close(w.done)
+	w.stopped.Wait()
+
+	err := w.sync()
+	if closeErr := w.file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("cannot close log: %w", closeErr)
+	}
+	return err
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"gitlab.com/iruldev/grpc-class/engine/repository"
 	"gitlab.com/iruldev/grpc-class/proto"
 	"google.golang.org/grpc/codes"
@@ -23,10 +24,11 @@ func NewAuthService(userRepository repository.UserRepository, tokenMaker *JWT) *
 
 func (s *AuthService) Login(ctx context.Context, req *proto.LoginRequest) (*proto.LoginResponse, error) {
 	user, err := s.UserRepository.Find(req.GetUsername())
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "cannot find user: %v", err)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, statusError(err, "cannot find user")
 	}
 
+	// a missing user and a wrong password look the same to the client
 	if user == nil || !user.IsCorrectPassword(req.GetPassword()) {
 		return nil, status.Errorf(codes.NotFound, "incorrect username/password")
 	}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError converts an error returned by a repository into a gRPC status
+// error whose message starts with the formatted text. Errors that already
+// carry a status, such as a canceled context, keep their code.
+func statusError(err error, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	if s, ok := status.FromError(err); ok {
+		return status.Errorf(s.Code(), "%s: %s", message, s.Message())
+	}
+
+	return status.Errorf(errorCode(err), "%s: %v", message, err)
+}
+
+// errorCode returns the gRPC code that matches a repository error.
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, repository.ErrVersionMismatch):
+		return codes.Aborted
+	default:
+		return codes.Internal
+	}
+}
+
+// receiveError converts an error received from a stream into an Unknown
+// status error. Errors that already carry a status, such as the rejections
+// of the validation interceptor, are returned unchanged so that their code
+// and details reach the client.
+func receiveError(err error, format string, args ...interface{}) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Errorf(codes.Unknown, "%s: %v", fmt.Sprintf(format, args...), err)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/model/entity"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestStatusError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"not_found", repository.ErrNotFound, codes.NotFound},
+		{"wrapped_not_found", fmt.Errorf("cannot find laptop: %w", repository.ErrNotFound), codes.NotFound},
+		{"already_exists", repository.ErrAlreadyExists, codes.AlreadyExists},
+		{"version_mismatch", repository.ErrVersionMismatch, codes.Aborted},
+		{"status", status.Error(codes.Canceled, "request is canceled"), codes.Canceled},
+		{"other", errors.New("disk is full"), codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := statusError(tc.err, "cannot find laptop %s", "abc")
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, tc.code, st.Code())
+			require.Contains(t, st.Message(), "cannot find laptop abc: ")
+		})
+	}
+}
+
+func TestServiceLogin(t *testing.T) {
+	t.Parallel()
+
+	userRepo := repository.NewUserRepository()
+	user, err := entity.NewUser("admin", "secret", "admin")
+	require.NoError(t, err)
+	require.NoError(t, userRepo.Save(user))
+
+	server := NewAuthService(userRepo, NewJWTService("secret", time.Minute))
+
+	testCases := []struct {
+		name     string
+		username string
+		password string
+		code     codes.Code
+	}{
+		{"success", "admin", "secret", codes.OK},
+		{"wrong_password", "admin", "wrong", codes.NotFound},
+		{"unknown_user", "unknown", "secret", codes.NotFound},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := &proto.LoginRequest{Username: tc.username, Password: tc.password}
+			res, err := server.Login(context.Background(), req)
+			require.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.OK {
+				require.NotEmpty(t, res.GetAccessToken())
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"strings"
+)
+
+// applyFieldMask copies the fields named by paths from src into dst. Nested
+// fields are addressed with dot-separated paths such as "cpu.min_ghz"; a field
+// that is unset in src is cleared in dst.
+func applyFieldMask(dst, src protoreflect.Message, paths []string) error {
+	for _, path := range paths {
+		err := applyFieldPath(dst, src, strings.Split(path, "."))
+		if err != nil {
+			return fmt.Errorf("invalid field mask path %q: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func applyFieldPath(dst, src protoreflect.Message, names []string) error {
+	field := dst.Descriptor().Fields().ByName(protoreflect.Name(names[0]))
+	if field == nil {
+		return fmt.Errorf("unknown field %s", names[0])
+	}
+
+	if len(names) == 1 {
+		if src.Has(field) {
+			dst.Set(field, src.Get(field))
+		} else {
+			dst.Clear(field)
+		}
+		return nil
+	}
+
+	if field.Message() == nil || field.IsList() || field.IsMap() {
+		return fmt.Errorf("field %s has no sub-fields", names[0])
+	}
+
+	return applyFieldPath(dst.Mutable(field).Message(), src.Get(field).Message(), names[1:])
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"sync"
+)
+
+// keyedMutex holds a mutex per key, kept only while it is locked or waited
+// for. The zero value is ready to use.
+type keyedMutex struct {
+	mutex sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks the mutex of the key and returns the function unlocking it.
+func (m *keyedMutex) lock(key string) func() {
+	m.mutex.Lock()
+	if m.locks == nil {
+		m.locks = make(map[string]*keyedLock)
+	}
+	l := m.locks[key]
+	if l == nil {
+		l = &keyedLock{}
+		m.locks[key] = l
+	}
+	l.refs++
+	m.mutex.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(m.locks, key)
+		}
+	}
+}
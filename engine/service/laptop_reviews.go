@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/engine/validator"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"time"
+)
+
+// CreateReview saves a review and rates the laptop with its score, as the
+// score of a review is the rating of its author. The changes of the reviews
+// are undone when the rating cannot follow them.
+func (s *LaptopService) CreateReview(ctx context.Context, req *proto.CreateReviewRequest) (*proto.CreateReviewResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a create-review request for laptop %s by %s", laptopID, caller(ctx))
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	username, err := requireUsername(ctx)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	err = s.checkReviewScore(req.GetScore())
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	_, err = s.LaptopRepository.Find(laptopID)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot find laptop %s", laptopID))
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, logError(status.Errorf(codes.Internal, "cannot generate a new review ID: %v", err))
+	}
+
+	defer s.lockRater(laptopID, username)()
+	review := &proto.Review{
+		Id:        id.String(),
+		LaptopId:  laptopID,
+		Author:    username,
+		Title:     req.GetTitle(),
+		Body:      req.GetBody(),
+		Score:     req.GetScore(),
+		CreatedAt: timestamppb.Now(),
+	}
+	err = s.ReviewRepository.Save(review)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot save review of laptop %s", laptopID))
+	}
+
+	_, err = s.RatingRepository.Rate(laptopID, username, review.Score)
+	if err != nil {
+		s.undoReview(s.ReviewRepository.Delete(review.Id))
+		return nil, logError(statusError(err, "cannot rate laptop %s", laptopID))
+	}
+
+	log.Printf("saved review with id: %s", review.Id)
+	return &proto.CreateReviewResponse{Review: review}, nil
+}
+
+func (s *LaptopService) EditReview(ctx context.Context, req *proto.EditReviewRequest) (*proto.EditReviewResponse, error) {
+	id := req.GetId()
+	log.Printf("receive an edit-review request with id: %s by %s", id, caller(ctx))
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	username, err := requireUsername(ctx)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	err = s.checkReviewScore(req.GetScore())
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	review, unlock, err := s.lockReview(id)
+	if err != nil {
+		return nil, logError(err)
+	}
+	defer unlock()
+	if review.Author != username {
+		return nil, logError(status.Errorf(codes.PermissionDenied, "cannot edit the review of another user"))
+	}
+
+	previous := protobuf.Clone(review).(*proto.Review)
+	review.Title = req.GetTitle()
+	review.Body = req.GetBody()
+	review.Score = req.GetScore()
+	review.EditedAt = timestamppb.Now()
+
+	err = s.ReviewRepository.Update(review)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot save review %s", id))
+	}
+
+	_, err = s.RatingRepository.Rate(review.LaptopId, username, review.Score)
+	if err != nil {
+		s.undoReview(s.ReviewRepository.Update(previous))
+		return nil, logError(statusError(err, "cannot rate laptop %s", review.LaptopId))
+	}
+
+	return &proto.EditReviewResponse{Review: review}, nil
+}
+
+func (s *LaptopService) DeleteReview(ctx context.Context, req *proto.DeleteReviewRequest) (*proto.DeleteReviewResponse, error) {
+	id := req.GetId()
+	log.Printf("receive a delete-review request with id: %s by %s", id, caller(ctx))
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	username, err := requireUsername(ctx)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	review, unlock, err := s.lockReview(id)
+	if err != nil {
+		return nil, logError(err)
+	}
+	defer unlock()
+	if review.Author != username && !isAdmin(ctx) {
+		return nil, logError(status.Errorf(codes.PermissionDenied, "cannot delete the review of another user"))
+	}
+
+	err = s.ReviewRepository.Delete(id)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot delete review %s", id))
+	}
+
+	_, err = s.RatingRepository.Retract(review.LaptopId, review.Author)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		s.undoReview(s.ReviewRepository.Save(review))
+		return nil, logError(statusError(err, "cannot retract rating of laptop %s", review.LaptopId))
+	}
+
+	return &proto.DeleteReviewResponse{}, nil
+}
+
+func (s *LaptopService) ListReviews(ctx context.Context, req *proto.ListReviewsRequest) (*proto.ListReviewsResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a list-reviews request for laptop %s, page size: %d", laptopID, req.GetPageSize())
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.GetIncludeHidden() && !isAdmin(ctx) {
+		return nil, logError(status.Errorf(codes.PermissionDenied, "only admins can list hidden reviews"))
+	}
+
+	_, err := s.LaptopRepository.Find(laptopID)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot find laptop %s", laptopID))
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	// one more review tells whether there is a next page
+	options := repository.ReviewListOptions{Limit: pageSize + 1, IncludeHidden: req.GetIncludeHidden()}
+	if len(req.GetPageToken()) > 0 {
+		options.After, err = s.decodeReviewPageToken(laptopID, req.GetPageToken())
+		if err != nil {
+			return nil, logError(status.Errorf(codes.InvalidArgument, "invalid page token: %v", err))
+		}
+	}
+
+	reviews, err := s.ReviewRepository.List(laptopID, options)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot list reviews of laptop %s", laptopID))
+	}
+
+	res := &proto.ListReviewsResponse{Reviews: reviews}
+	if len(reviews) > pageSize {
+		res.Reviews = reviews[:pageSize]
+		res.NextPageToken, err = s.encodeReviewPageToken(laptopID, res.Reviews[pageSize-1])
+		if err != nil {
+			return nil, logError(status.Errorf(codes.Internal, "cannot create page token: %v", err))
+		}
+	}
+
+	log.Printf("listed %d reviews", len(res.Reviews))
+	return res, nil
+}
+
+func (s *LaptopService) HideReview(ctx context.Context, req *proto.HideReviewRequest) (*proto.HideReviewResponse, error) {
+	id := req.GetId()
+	log.Printf("receive a hide-review request with id: %s, hidden: %t by %s", id, req.GetHidden(), caller(ctx))
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	if !isAdmin(ctx) {
+		return nil, logError(status.Errorf(codes.PermissionDenied, "only admins can hide reviews"))
+	}
+
+	review, err := s.ReviewRepository.SetHidden(id, req.GetHidden())
+	if err != nil {
+		return nil, logError(statusError(err, "cannot save review %s", id))
+	}
+
+	return &proto.HideReviewResponse{Review: review}, nil
+}
+
+// checkReviewScore returns an InvalidArgument status if the score is not on
+// the rating scale of the service.
+func (s *LaptopService) checkReviewScore(score float64) error {
+	var violations validator.Violations
+	err := s.RatingScale.Check(score)
+	if err != nil {
+		violations.Add("score", err.Error())
+	}
+	return violations.Err("invalid review")
+}
+
+// checkNotReviewed returns a FailedPrecondition status if the user has
+// reviewed the laptop, whose rating then follows the review.
+func (s *LaptopService) checkNotReviewed(laptopID, username string) error {
+	if s.ReviewRepository == nil {
+		return nil
+	}
+
+	_, err := s.ReviewRepository.FindByAuthor(laptopID, username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return statusError(err, "cannot find review of laptop %s", laptopID)
+	}
+
+	return status.Errorf(codes.FailedPrecondition, "laptop %s is reviewed by %s, edit or delete the review instead", laptopID, username)
+}
+
+// lockRater locks the rating of a user of a laptop, which a review of the
+// user sets, and returns the function unlocking it.
+func (s *LaptopService) lockRater(laptopID, username string) func() {
+	return s.raters.lock(laptopID + "\x00" + username)
+}
+
+// lockReview finds a review and locks the rating of its author. The review
+// is read again under the lock, as it may have changed in the meantime.
+func (s *LaptopService) lockReview(id string) (*proto.Review, func(), error) {
+	review, err := s.ReviewRepository.Find(id)
+	if err != nil {
+		return nil, nil, statusError(err, "cannot find review %s", id)
+	}
+
+	unlock := s.lockRater(review.LaptopId, review.Author)
+	review, err = s.ReviewRepository.Find(id)
+	if err != nil {
+		unlock()
+		return nil, nil, statusError(err, "cannot find review %s", id)
+	}
+	return review, unlock, nil
+}
+
+// undoReview logs the failure to undo a change of a review, which leaves the
+// review out of sync with the rating of its author.
+func (s *LaptopService) undoReview(err error) {
+	if err != nil {
+		log.Print("cannot undo review change: ", err)
+	}
+}
+
+// reviewsOf names the list of reviews of a laptop in page tokens, so a
+// token is only accepted for the list it was issued for.
+func reviewsOf(laptopID string) string {
+	return "reviews of " + laptopID
+}
+
+func (s *LaptopService) encodeReviewPageToken(laptopID string, last *proto.Review) (string, error) {
+	position := repository.PositionOf(last)
+	return s.pageTokens.encode(&pageToken{
+		OrderBy: reviewsOf(laptopID),
+		Values:  []interface{}{position.CreatedAt.Format(time.RFC3339Nano), position.ID},
+	})
+}
+
+func (s *LaptopService) decodeReviewPageToken(laptopID, value string) (*repository.ReviewPosition, error) {
+	token, err := s.pageTokens.decode(value)
+	if err != nil {
+		return nil, err
+	}
+	if token.OrderBy != reviewsOf(laptopID) {
+		return nil, errors.New("page token was issued for a different list")
+	}
+
+	if len(token.Values) != 2 {
+		return nil, fmt.Errorf("page token has %d values instead of 2", len(token.Values))
+	}
+	createdAt, ok := token.Values[0].(string)
+	if !ok {
+		return nil, errors.New("page token has no creation time")
+	}
+	id, ok := token.Values[1].(string)
+	if !ok {
+		return nil, errors.New("page token has no review ID")
+	}
+
+	position := &repository.ReviewPosition{ID: id}
+	position.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid creation time: %w", err)
+	}
+	return position, nil
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"gitlab.com/iruldev/grpc-class/sample"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"testing"
+)
+
+func newTestReviewService(t *testing.T) (*LaptopService, *proto.Laptop) {
+	store := repository.NewLaptopRepository()
+	laptop := sample.NewLaptop()
+	require.NoError(t, store.Save(laptop))
+
+	service := NewLaptopService(store, nil, repository.NewRatingRepository(), repository.NewReviewRepository())
+	return service, laptop
+}
+
+func userContext(username, role string) context.Context {
+	return ContextWithUser(context.Background(), &UserClaims{Username: username, Role: role})
+}
+
+func TestServiceReviews(t *testing.T) {
+	t.Parallel()
+
+	service, laptop := newTestReviewService(t)
+	alice := userContext("alice", "user")
+	req := &proto.CreateReviewRequest{LaptopId: laptop.Id, Title: "Fast", Body: "Builds in no time", Score: 9}
+
+	_, err := service.CreateReview(context.Background(), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = service.CreateReview(alice, &proto.CreateReviewRequest{LaptopId: laptop.Id, Title: "Fast", Score: 11})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = service.CreateReview(alice, &proto.CreateReviewRequest{LaptopId: "unknown", Title: "Fast", Score: 9})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// the score of the review replaces the rating of the author
+	_, err = service.RatingRepository.Rate(laptop.Id, "alice", 2)
+	require.NoError(t, err)
+	_, err = service.RatingRepository.Rate(laptop.Id, "bob", 5)
+	require.NoError(t, err)
+
+	created, err := service.CreateReview(alice, req)
+	require.NoError(t, err)
+	review := created.GetReview()
+	require.NotEmpty(t, review.GetId())
+	require.Equal(t, "alice", review.GetAuthor())
+	require.NotNil(t, review.GetCreatedAt())
+	require.Nil(t, review.GetEditedAt())
+	requireRating(t, service, laptop.Id, 2, 7)
+
+	_, err = service.CreateReview(alice, req)
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// the rating of a reviewer follows the review
+	_, err = service.RetractRating(alice, &proto.RetractRatingRequest{LaptopId: laptop.Id})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = service.rate(alice, &proto.RateLaptopRequest{LaptopId: laptop.Id, Score: 1})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	edit := &proto.EditReviewRequest{Id: review.GetId(), Title: "Fast and quiet", Score: 7}
+	_, err = service.EditReview(userContext("bob", "user"), edit)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	edited, err := service.EditReview(alice, edit)
+	require.NoError(t, err)
+	require.Equal(t, "Fast and quiet", edited.GetReview().GetTitle())
+	require.Empty(t, edited.GetReview().GetBody())
+	require.NotNil(t, edited.GetReview().GetEditedAt())
+	requireRating(t, service, laptop.Id, 2, 6)
+
+	_, err = service.EditReview(alice, &proto.EditReviewRequest{Id: "unknown", Title: "Fast", Score: 7})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// admins delete the reviews of other users
+	deleteReq := &proto.DeleteReviewRequest{Id: review.GetId()}
+	_, err = service.DeleteReview(userContext("bob", "user"), deleteReq)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = service.DeleteReview(userContext("root", "admin"), deleteReq)
+	require.NoError(t, err)
+	requireRating(t, service, laptop.Id, 1, 5)
+
+	_, err = service.DeleteReview(alice, deleteReq)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// without a review the user rates the laptop again
+	_, err = service.rate(alice, &proto.RateLaptopRequest{LaptopId: laptop.Id, Score: 1})
+	require.NoError(t, err)
+}
+
+func TestServiceReviewUndoneWithoutRating(t *testing.T) {
+	t.Parallel()
+
+	service, laptop := newTestReviewService(t)
+	service.RatingRepository = failingRatingRepository{service.RatingRepository}
+
+	req := &proto.CreateReviewRequest{LaptopId: laptop.Id, Title: "Fast", Score: 9}
+	_, err := service.CreateReview(userContext("alice", "user"), req)
+	require.Equal(t, codes.Internal, status.Code(err))
+
+	_, err = service.ReviewRepository.FindByAuthor(laptop.Id, "alice")
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestServiceConcurrentReviewChanges(t *testing.T) {
+	t.Parallel()
+
+	service, laptop := newTestReviewService(t)
+	alice := userContext("alice", "user")
+	admin := userContext("root", "admin")
+
+	created, err := service.CreateReview(alice, &proto.CreateReviewRequest{LaptopId: laptop.Id, Title: "Fast", Score: 9})
+	require.NoError(t, err)
+	id := created.GetReview().GetId()
+
+	// edits race with hiding the review, and then with deleting it
+	race := func(change func(i int)) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				change(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+	edit := func(i int) {
+		_, _ = service.EditReview(alice, &proto.EditReviewRequest{Id: id, Title: "Edited", Score: float64(i%10 + 1)})
+	}
+
+	race(func(i int) {
+		if i%2 == 0 {
+			_, _ = service.HideReview(admin, &proto.HideReviewRequest{Id: id, Hidden: true})
+			return
+		}
+		edit(i)
+	})
+
+	// no edit shows the review again and the rating follows the last one
+	review, err := service.ReviewRepository.Find(id)
+	require.NoError(t, err)
+	require.True(t, review.GetHidden())
+	requireRating(t, service, laptop.Id, 1, review.GetScore())
+
+	race(func(i int) {
+		if i == 10 {
+			_, _ = service.DeleteReview(alice, &proto.DeleteReviewRequest{Id: id})
+			return
+		}
+		edit(i)
+	})
+
+	_, err = service.RatingRepository.Find(laptop.Id)
+	require.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestServiceListReviews(t *testing.T) {
+	t.Parallel()
+
+	service, laptop := newTestReviewService(t)
+	admin := userContext("root", "admin")
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		req := &proto.CreateReviewRequest{LaptopId: laptop.Id, Title: fmt.Sprintf("Review %d", i), Score: 8}
+		res, err := service.CreateReview(userContext(fmt.Sprintf("user-%d", i), "user"), req)
+		require.NoError(t, err)
+		ids = append(ids, res.GetReview().GetId())
+	}
+
+	_, err := service.HideReview(userContext("user-0", "user"), &proto.HideReviewRequest{Id: ids[1], Hidden: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	hidden, err := service.HideReview(admin, &proto.HideReviewRequest{Id: ids[1], Hidden: true})
+	require.NoError(t, err)
+	require.True(t, hidden.GetReview().GetHidden())
+
+	// hidden reviews still count in the rating
+	requireRating(t, service, laptop.Id, 5, 8)
+
+	listAll := func(ctx context.Context, includeHidden bool) []string {
+		var listed []string
+		req := &proto.ListReviewsRequest{LaptopId: laptop.Id, PageSize: 2, IncludeHidden: includeHidden}
+		for {
+			res, err := service.ListReviews(ctx, req)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(res.GetReviews()), 2)
+			for _, review := range res.GetReviews() {
+				listed = append(listed, review.GetId())
+			}
+
+			if len(res.GetNextPageToken()) == 0 {
+				return listed
+			}
+			req.PageToken = res.GetNextPageToken()
+		}
+	}
+
+	// the newest reviews come first
+	require.Equal(t, []string{ids[4], ids[3], ids[2], ids[0]}, listAll(userContext("user-0", "user"), false))
+	require.Equal(t, []string{ids[4], ids[3], ids[2], ids[1], ids[0]}, listAll(admin, true))
+
+	_, err = service.ListReviews(userContext("user-0", "user"), &proto.ListReviewsRequest{LaptopId: laptop.Id, IncludeHidden: true})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = service.ListReviews(admin, &proto.ListReviewsRequest{LaptopId: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// a page token is only valid for the laptop it was issued for
+	other := sample.NewLaptop()
+	require.NoError(t, service.LaptopRepository.Save(other))
+
+	res, err := service.ListReviews(admin, &proto.ListReviewsRequest{LaptopId: laptop.Id, PageSize: 1})
+	require.NoError(t, err)
+	_, err = service.ListReviews(admin, &proto.ListReviewsRequest{LaptopId: other.Id, PageToken: res.GetNextPageToken()})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = service.ListReviews(admin, &proto.ListReviewsRequest{LaptopId: laptop.Id, PageToken: "forged"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func requireRating(t *testing.T, service *LaptopService, laptopID string, count uint32, average float64) {
+	rating, err := service.RatingRepository.Find(laptopID)
+	require.NoError(t, err)
+	require.Equal(t, count, rating.Count)
+	require.Equal(t, average, rating.Average())
+}
+
+// failingRatingRepository cannot rate laptops.
+type failingRatingRepository struct {
+	repository.RatingRepository
+}
+
+func (r failingRatingRepository) Rate(laptopID, username string, score float64) (*repository.Rating, error) {
+	return nil, errors.New("rating store is unavailable")
+}
@@ -6,37 +6,64 @@ import (
 	"errors"
 	"github.com/google/uuid"
 	"gitlab.com/iruldev/grpc-class/engine/repository"
+	"gitlab.com/iruldev/grpc-class/engine/validator"
 	"gitlab.com/iruldev/grpc-class/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
 	"io"
 	"log"
+	"sort"
+	"strings"
 )
 
-const maxImageSize = 1 << 20 // 1 megabyte
+const (
+	maxImageSize    = 1 << 20 // 1 megabyte
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// maxMergeAttempts bounds the retries of a masked update that races
+	// with other updates of the laptop.
+	maxMergeAttempts = 3
+)
 
 type LaptopService struct {
 	proto.UnimplementedLaptopServiceServer
 	LaptopRepository repository.LaptopRepository
 	ImageRepository  repository.ImageRepository
 	RatingRepository repository.RatingRepository
+	ReviewRepository repository.ReviewRepository
+	RatingScale      RatingScale
+	pageTokens       *pageTokenSigner
+	// raters serializes the changes of a rating with the review setting it
+	raters keyedMutex
 }
 
 func NewLaptopService(
 	laptopRepository repository.LaptopRepository,
 	imageRepository repository.ImageRepository,
 	ratingRepository repository.RatingRepository,
+	reviewRepository repository.ReviewRepository,
 ) *LaptopService {
 	return &LaptopService{
 		LaptopRepository: laptopRepository,
 		ImageRepository:  imageRepository,
 		RatingRepository: ratingRepository,
+		ReviewRepository: reviewRepository,
+		RatingScale:      DefaultRatingScale,
+		pageTokens:       newPageTokenSigner(nil),
 	}
 }
 
+// SetPageTokenKey signs the page tokens with the key, so the tokens stay
+// valid across restarts and on every server sharing it. Without a key the
+// service signs them with a random key of its own.
+func (s *LaptopService) SetPageTokenKey(key []byte) {
+	s.pageTokens = newPageTokenSigner(key)
+}
+
 func (s *LaptopService) CreateLaptop(ctx context.Context, req *proto.CreateLaptopRequest) (*proto.CreateLaptopResponse, error) {
 	laptop := req.GetLaptop()
-	log.Printf("receive a create-laptop request with id: %s", laptop.Id)
+	log.Printf("receive a create-laptop request with id: %s by %s", laptop.Id, caller(ctx))
 
 	if len(laptop.Id) > 0 {
 		//	Check if it's a valid UUID
@@ -67,11 +94,7 @@ func (s *LaptopService) CreateLaptop(ctx context.Context, req *proto.CreateLapto
 	// save the laptop to store
 	err := s.LaptopRepository.Save(laptop)
 	if err != nil {
-		code := codes.Internal
-		if errors.Is(err, repository.ErrAlreadyExists) {
-			code = codes.AlreadyExists
-		}
-		return nil, status.Errorf(code, "cannot save laptop to the db: %v", err)
+		return nil, statusError(err, "cannot save laptop to the db")
 	}
 
 	log.Printf("saved laptop with id: %s", laptop.Id)
@@ -79,12 +102,211 @@ func (s *LaptopService) CreateLaptop(ctx context.Context, req *proto.CreateLapto
 	return res, nil
 }
 
+func (s *LaptopService) GetLaptop(ctx context.Context, req *proto.GetLaptopRequest) (*proto.GetLaptopResponse, error) {
+	laptopID := req.GetId()
+	log.Printf("receive a get-laptop request with id: %s", laptopID)
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	laptop, err := s.LaptopRepository.Find(laptopID)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot find laptop %s", laptopID))
+	}
+
+	res := &proto.GetLaptopResponse{Laptop: laptop}
+	return res, nil
+}
+
+func (s *LaptopService) UpdateLaptop(ctx context.Context, req *proto.UpdateLaptopRequest) (*proto.UpdateLaptopResponse, error) {
+	laptop := req.GetLaptop()
+	log.Printf("receive an update-laptop request with id: %s by %s", laptop.GetId(), caller(ctx))
+
+	if len(laptop.GetId()) == 0 {
+		return nil, logError(status.Error(codes.InvalidArgument, "laptop ID is required"))
+	}
+
+	paths := req.GetUpdateMask().GetPaths()
+	for _, path := range paths {
+		if path == "id" {
+			return nil, logError(status.Error(codes.InvalidArgument, "laptop ID cannot be updated"))
+		}
+	}
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	var updated *proto.Laptop
+	for attempt := 1; ; attempt++ {
+		merged, err := s.mergeLaptop(laptop, paths)
+		if err != nil {
+			return nil, logError(err)
+		}
+
+		updated, err = s.LaptopRepository.Update(merged)
+		// a merge with a laptop changed in the meantime is redone on the new
+		// version, unless the client asked for a version of its own
+		if errors.Is(err, repository.ErrVersionMismatch) && laptop.GetVersion() == 0 && attempt < maxMergeAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, logError(statusError(err, "cannot update laptop in the db"))
+		}
+		break
+	}
+
+	log.Printf("updated laptop with id: %s to version %d", updated.GetId(), updated.GetVersion())
+	res := &proto.UpdateLaptopResponse{Laptop: updated}
+	return res, nil
+}
+
+// mergeLaptop returns the laptop to store for an update. With a mask it is
+// the stored laptop with the masked fields replaced, versioned so that the
+// repository rejects it if the stored laptop changes before it is written.
+func (s *LaptopService) mergeLaptop(laptop *proto.Laptop, paths []string) (*proto.Laptop, error) {
+	found, err := s.LaptopRepository.Find(laptop.GetId())
+	if err != nil {
+		return nil, statusError(err, "cannot find laptop %s", laptop.GetId())
+	}
+
+	// the version sent by the client is the one it expects to overwrite, an
+	// empty mask without a version replaces the whole laptop unconditionally
+	version := laptop.GetVersion()
+	if len(paths) == 0 {
+		found = protobuf.Clone(laptop).(*proto.Laptop)
+	} else {
+		err = applyFieldMask(found.ProtoReflect(), laptop.ProtoReflect(), paths)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot apply update mask: %v", err)
+		}
+		if version == 0 {
+			version = found.GetVersion()
+		}
+	}
+
+	if err := validator.ValidateAt("laptop", found); err != nil {
+		return nil, err
+	}
+
+	found.Version = version
+	return found, nil
+}
+
+func (s *LaptopService) DeleteLaptop(ctx context.Context, req *proto.DeleteLaptopRequest) (*proto.DeleteLaptopResponse, error) {
+	laptopID := req.GetId()
+	log.Printf("receive a delete-laptop request with id: %s by %s", laptopID, caller(ctx))
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	err := s.LaptopRepository.Delete(laptopID, req.GetVersion())
+	if err != nil {
+		return nil, logError(statusError(err, "cannot delete laptop %s", laptopID))
+	}
+
+	log.Printf("deleted laptop with id: %s", laptopID)
+	return &proto.DeleteLaptopResponse{}, nil
+}
+
+func (s *LaptopService) ListLaptops(ctx context.Context, req *proto.ListLaptopsRequest) (*proto.ListLaptopsResponse, error) {
+	log.Printf("receive a list-laptops request with order: %q, page size: %d", req.GetOrderBy(), req.GetPageSize())
+
+	order, err := parseOrder(req.GetOrderBy(), "")
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	var after []interface{}
+	if len(req.GetPageToken()) > 0 {
+		token, err := s.pageTokens.decode(req.GetPageToken())
+		if err != nil {
+			return nil, logError(status.Errorf(codes.InvalidArgument, "invalid page token: %v", err))
+		}
+		if token.OrderBy != order.String() {
+			return nil, logError(status.Error(codes.InvalidArgument, "page token was issued for a different order"))
+		}
+		if err := order.CheckValues(token.Values); err != nil {
+			return nil, logError(status.Errorf(codes.InvalidArgument, "invalid page token: %v", err))
+		}
+		after = token.Values
+	}
+
+	// one laptop more than the page tells whether there is a next page
+	options := repository.SearchOptions{
+		Order:  order,
+		Limit:  pageSize + 1,
+		After:  after,
+		Rating: s.averageScore,
+	}
+
+	var laptops []*proto.Laptop
+	err = s.LaptopRepository.Search(ctx, nil, options, func(laptop *proto.Laptop) error {
+		laptops = append(laptops, laptop)
+		return nil
+	})
+	if err != nil {
+		return nil, logError(statusError(err, "cannot list laptops"))
+	}
+
+	res := &proto.ListLaptopsResponse{}
+	if len(laptops) > pageSize {
+		laptops = laptops[:pageSize]
+		values := order.Values(laptops[pageSize-1], s.averageScore, nil)
+		res.NextPageToken, err = s.pageTokens.encode(&pageToken{OrderBy: order.String(), Values: values})
+		if err != nil {
+			return nil, logError(status.Errorf(codes.Internal, "cannot create page token: %v", err))
+		}
+	}
+	res.Laptops = laptops
+
+	log.Printf("listed %d laptops", len(res.Laptops))
+	return res, nil
+}
+
 func (s *LaptopService) SearchLaptop(req *proto.SearchLaptopRequest, stream proto.LaptopService_SearchLaptopServer) error {
 	filter := req.GetFilter()
-	log.Printf("receive a search-laptop request with filter: %v", filter)
+	log.Printf("receive a search-laptop request with filter: %v, query: %q, text: %q", filter, req.GetQuery(), req.GetText())
 
-	err := s.LaptopRepository.Search(stream.Context(), filter, func(laptop *proto.Laptop) error {
+	order, err := parseOrder(req.GetOrderBy(), req.GetText())
+	if err != nil {
+		return logError(err)
+	}
+
+	query, err := parseQuery(req.GetQuery())
+	if err != nil {
+		return err
+	}
+
+	options := repository.SearchOptions{
+		Query:  query,
+		Text:   req.GetText(),
+		Order:  order,
+		Limit:  int(req.GetMaxResults()),
+		Offset: int(req.GetOffset()),
+		Rating: s.averageScore,
+	}
+
+	err = s.LaptopRepository.Search(stream.Context(), filter, options, func(laptop *proto.Laptop) error {
 		res := &proto.SearchLaptopResponse{Laptop: laptop}
+		if req.GetIncludeRating() {
+			rating, err := s.findRatingSummary(laptop.GetId())
+			if err != nil {
+				return err
+			}
+			res.Rating = rating
+		}
+
 		err := stream.Send(res)
 		if err != nil {
 			return err
@@ -95,28 +317,61 @@ func (s *LaptopService) SearchLaptop(req *proto.SearchLaptopRequest, stream prot
 	})
 
 	if err != nil {
-		return status.Errorf(codes.Internal, "unexpected error: %v", err)
+		return logError(statusError(err, "cannot search laptops"))
 	}
 
 	return nil
 }
 
+func (s *LaptopService) SearchFacets(ctx context.Context, req *proto.SearchFacetsRequest) (*proto.SearchFacetsResponse, error) {
+	filter := req.GetFilter()
+	log.Printf("receive a search-facets request with filter: %v, query: %q, text: %q", filter, req.GetQuery(), req.GetText())
+
+	query, err := parseQuery(req.GetQuery())
+	if err != nil {
+		return nil, err
+	}
+
+	options := repository.SearchOptions{Query: query, Text: req.GetText()}
+	facets, err := s.LaptopRepository.Facets(ctx, filter, options)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot compute facets"))
+	}
+
+	res := &proto.SearchFacetsResponse{Total: facets.Total}
+	for _, name := range sortedKeys(facets.Buckets) {
+		facet := &proto.Facet{Name: name}
+		for value, count := range facets.Buckets[name] {
+			facet.Buckets = append(facet.Buckets, &proto.FacetBucket{Value: value, Count: count})
+		}
+		sort.Slice(facet.Buckets, func(i, j int) bool {
+			a, b := facet.Buckets[i], facet.Buckets[j]
+			return a.Count > b.Count || (a.Count == b.Count && a.Value < b.Value)
+		})
+		res.Facets = append(res.Facets, facet)
+	}
+
+	for _, name := range sortedKeys(facets.Ranges) {
+		r := facets.Ranges[name]
+		res.Ranges = append(res.Ranges, &proto.NumericRange{Name: name, Min: r.Min, Max: r.Max})
+	}
+
+	return res, nil
+}
+
 func (s *LaptopService) UploadImage(stream proto.LaptopService_UploadImageServer) error {
 	req, err := stream.Recv()
 	if err != nil {
-		return logError(status.Errorf(codes.Unknown, "cannot receive image info: %v", err))
+		return logError(receiveError(err, "cannot receive image info"))
 	}
 
 	laptopID := req.GetInfo().GetLaptopId()
 	imageType := req.GetInfo().GetImageType()
-	log.Printf("receive an upload-image request for laptop %s with image type %s", laptopID, imageType)
+	log.Printf("receive an upload-image request for laptop %s with image type %s by %s", laptopID, imageType, caller(stream.Context()))
 
-	laptop, err := s.LaptopRepository.Find(laptopID)
+	_, err = s.LaptopRepository.Find(laptopID)
 	if err != nil {
-		return logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
-	}
-	if laptop == nil {
-		return logError(status.Errorf(codes.InvalidArgument, "laptop %s doesn't exist", laptopID))
+		return logError(statusError(err, "cannot find laptop %s", laptopID))
 	}
 
 	imageData := bytes.Buffer{}
@@ -134,7 +389,7 @@ func (s *LaptopService) UploadImage(stream proto.LaptopService_UploadImageServer
 			break
 		}
 		if err != nil {
-			return logError(status.Errorf(codes.Unknown, "cannot receive chunk data: %v", err))
+			return logError(receiveError(err, "cannot receive chunk data"))
 		}
 
 		chuck := req.GetChunkData()
@@ -156,7 +411,7 @@ func (s *LaptopService) UploadImage(stream proto.LaptopService_UploadImageServer
 
 	imageID, err := s.ImageRepository.Save(laptopID, imageType, imageData)
 	if err != nil {
-		return logError(status.Errorf(codes.Internal, "cannot save image to db: %v", err))
+		return logError(statusError(err, "cannot save image to db"))
 	}
 
 	res := &proto.UploadImageRespons{
@@ -187,38 +442,301 @@ func (s *LaptopService) RateLaptop(stream proto.LaptopService_RateLaptopServer)
 			break
 		}
 		if err != nil {
-			return logError(status.Errorf(codes.Unknown, "cannot receive stream request: %v", err))
+			return logError(receiveError(err, "cannot receive stream request"))
 		}
-		laptopID := req.GetLaptopId()
-		score := req.GetScore()
-
-		log.Printf("received a rate-laptop request: id = %s, score = %.2f", laptopID, score)
+		log.Printf("received a rate-laptop request: id = %s, score = %.2f", req.GetLaptopId(), req.GetScore())
 
-		found, err := s.LaptopRepository.Find(laptopID)
+		// a failed rating is answered on its own so the stream goes on
+		res, err := s.rate(stream.Context(), req)
 		if err != nil {
-			return logError(status.Errorf(codes.Internal, "cannot find laptop: %v", err))
+			res = &proto.RateLaptopResponse{LaptopId: req.GetLaptopId(), Status: status.Convert(logError(err)).Proto()}
 		}
-		if found == nil {
-			return logError(status.Errorf(codes.NotFound, "laptop id %s is not found", laptopID))
+		res.RequestId = req.GetRequestId()
+
+		err = stream.Send(res)
+		if err != nil {
+			return logError(status.Errorf(codes.Unknown, "cannot send stream response: %v", err))
 		}
+	}
+	return nil
+}
 
-		rating, err := s.RatingRepository.Add(laptopID, score)
+// rate sets the score of the user of the RateLaptop stream.
+func (s *LaptopService) rate(ctx context.Context, req *proto.RateLaptopRequest) (*proto.RateLaptopResponse, error) {
+	username, err := requireUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkRating(req)
+	if err != nil {
+		return nil, err
+	}
+
+	laptopID := req.GetLaptopId()
+	_, err = s.LaptopRepository.Find(laptopID)
+	if err != nil {
+		return nil, statusError(err, "cannot find laptop %s", laptopID)
+	}
+
+	defer s.lockRater(laptopID, username)()
+	err = s.checkNotReviewed(laptopID, username)
+	if err != nil {
+		return nil, err
+	}
+
+	rating, err := s.RatingRepository.Rate(laptopID, username, req.GetScore())
+	if err != nil {
+		return nil, statusError(err, "cannot add rating to the store")
+	}
+
+	res := &proto.RateLaptopResponse{
+		LaptopId:     laptopID,
+		RatedCount:   rating.Count,
+		AverageScore: rating.Average(),
+		Status:       status.New(codes.OK, "").Proto(),
+	}
+	return res, nil
+}
+
+func (s *LaptopService) RetractRating(ctx context.Context, req *proto.RetractRatingRequest) (*proto.RetractRatingResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a retract-rating request with id: %s", laptopID)
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	username, err := requireUsername(ctx)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	defer s.lockRater(laptopID, username)()
+	err = s.checkNotReviewed(laptopID, username)
+	if err != nil {
+		return nil, logError(err)
+	}
+
+	rating, err := s.RatingRepository.Retract(laptopID, username)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot retract rating of laptop %s", laptopID))
+	}
+
+	return &proto.RetractRatingResponse{Rating: ratingSummary(laptopID, rating)}, nil
+}
+
+func (s *LaptopService) GetRating(ctx context.Context, req *proto.GetRatingRequest) (*proto.GetRatingResponse, error) {
+	laptopID := req.GetLaptopId()
+	log.Printf("receive a get-rating request with id: %s", laptopID)
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	_, err := s.LaptopRepository.Find(laptopID)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot find laptop %s", laptopID))
+	}
+
+	summary, err := s.findRatingSummary(laptopID)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot find rating of laptop %s", laptopID))
+	}
+
+	return &proto.GetRatingResponse{Rating: summary}, nil
+}
+
+func (s *LaptopService) GetRatings(ctx context.Context, req *proto.GetRatingsRequest) (*proto.GetRatingsResponse, error) {
+	laptopIDs := req.GetLaptopIds()
+	log.Printf("receive a get-ratings request for %d laptops", len(laptopIDs))
+
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	var found []string
+	for _, laptopID := range laptopIDs {
+		_, err := s.LaptopRepository.Find(laptopID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
 		if err != nil {
-			return logError(status.Errorf(codes.Internal, "cannot add rating to the store: %v", err))
+			return nil, logError(statusError(err, "cannot find laptop %s", laptopID))
 		}
+		found = append(found, laptopID)
+	}
+
+	ratings, err := s.RatingRepository.FindAll(found)
+	if err != nil {
+		return nil, logError(statusError(err, "cannot find ratings"))
+	}
+
+	res := &proto.GetRatingsResponse{}
+	for _, laptopID := range found {
+		res.Ratings = append(res.Ratings, ratingSummary(laptopID, ratings[laptopID]))
+	}
+	return res, nil
+}
+
+// defaultTopRatedLimit is the number of laptops TopRatedLaptops returns
+// without a limit.
+const defaultTopRatedLimit = 10
+
+func (s *LaptopService) TopRatedLaptops(ctx context.Context, req *proto.TopRatedLaptopsRequest) (*proto.TopRatedLaptopsResponse, error) {
+	filter := req.GetFilter()
+	log.Printf("receive a top-rated-laptops request with filter: %v, limit: %d", filter, req.GetLimit())
 
-		res := &proto.RateLaptopResponse{
-			LaptopId:     laptopID,
-			RatedCount:   rating.Count,
-			AverageScore: rating.Sum / float64(rating.Count),
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultTopRatedLimit
+	}
+
+	laptops := make(map[string]*proto.Laptop)
+	top, err := s.RatingRepository.TopRated(limit, func(laptopID string) (bool, error) {
+		if err := contextError(ctx); err != nil {
+			return false, err
 		}
 
-		err = stream.Send(res)
+		laptop, err := s.LaptopRepository.Find(laptopID)
+		if errors.Is(err, repository.ErrNotFound) {
+			// the ratings of a deleted laptop are kept
+			return false, nil
+		}
 		if err != nil {
-			return logError(status.Errorf(codes.Unknown, "cannot send stream response: %v", err))
+			return false, err
+		}
+
+		if !repository.IsQualified(filter, laptop) {
+			return false, nil
 		}
+		laptops[laptopID] = laptop
+		return true, nil
+	})
+	if err != nil {
+		return nil, logError(statusError(err, "cannot rank laptops"))
 	}
-	return nil
+
+	res := &proto.TopRatedLaptopsResponse{}
+	for _, ranked := range top {
+		res.Laptops = append(res.Laptops, &proto.RankedLaptop{
+			Laptop: laptops[ranked.LaptopID],
+			Score:  ranked.Score,
+			Rating: ratingSummary(ranked.LaptopID, ranked.Rating),
+		})
+	}
+	return res, nil
+}
+
+// checkRating returns an InvalidArgument status if the rating is not on the
+// rating scale of the service.
+func (s *LaptopService) checkRating(req *proto.RateLaptopRequest) error {
+	var violations validator.Violations
+	if len(req.GetLaptopId()) == 0 {
+		violations.Add("laptop_id", "is required")
+	}
+
+	err := s.RatingScale.Check(req.GetScore())
+	if err != nil {
+		violations.Add("score", err.Error())
+	}
+
+	return violations.Err("invalid rating")
+}
+
+// averageScore returns the average rating of the laptop, or zero if it has
+// not been rated yet.
+func (s *LaptopService) averageScore(laptopID string) float64 {
+	if s.RatingRepository == nil {
+		return 0
+	}
+
+	rating, err := s.RatingRepository.Find(laptopID)
+	if err != nil {
+		return 0
+	}
+
+	return rating.Average()
+}
+
+// findRatingSummary returns the rating summary of a laptop, which is empty if
+// the laptop has not been rated yet.
+func (s *LaptopService) findRatingSummary(laptopID string) (*proto.RatingSummary, error) {
+	if s.RatingRepository == nil {
+		return ratingSummary(laptopID, nil), nil
+	}
+
+	rating, err := s.RatingRepository.Find(laptopID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ratingSummary(laptopID, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ratingSummary(laptopID, rating), nil
+}
+
+func ratingSummary(laptopID string, rating *repository.Rating) *proto.RatingSummary {
+	summary := &proto.RatingSummary{LaptopId: laptopID}
+	if rating == nil {
+		return summary
+	}
+
+	summary.Count = rating.Count
+	summary.Average = rating.Average()
+	summary.Min = rating.Min
+	summary.Max = rating.Max
+
+	buckets := make([]int, 0, len(rating.Histogram))
+	for bucket := range rating.Histogram {
+		buckets = append(buckets, bucket)
+	}
+	sort.Ints(buckets)
+
+	for _, bucket := range buckets {
+		summary.Histogram = append(summary.Histogram, &proto.ScoreBucket{
+			Score: int32(bucket),
+			Count: rating.Histogram[bucket],
+		})
+	}
+	return summary
+}
+
+// parseOrder parses the order_by of a request, ordering by relevance needs
+// a search text.
+func parseOrder(orderBy, text string) (repository.Order, error) {
+	order, err := repository.ParseOrder(orderBy)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order by: %v", err)
+	}
+	if strings.TrimSpace(text) == "" && order.Has(repository.OrderByRelevance) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order by: %s needs a search text", repository.OrderByRelevance)
+	}
+	return order, nil
+}
+
+// parseQuery parses the optional query text of a search request.
+func parseQuery(text string) (repository.Query, error) {
+	if len(text) == 0 {
+		return nil, nil
+	}
+
+	query, err := repository.ParseQuery(text)
+	if err != nil {
+		return nil, logError(status.Errorf(codes.InvalidArgument, "invalid query: %v", err))
+	}
+
+	return query, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func logError(err error) error {
@@ -10,9 +10,13 @@ import (
 	"gitlab.com/iruldev/grpc-class/sample"
 	"gitlab.com/iruldev/grpc-class/serializer"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"io"
 	"log"
+	"math"
 	"net"
 	"os"
 	"path/filepath"
@@ -47,10 +51,13 @@ func TestClientCreateLaptop(t *testing.T) {
 func TestClientSearchLaptop(t *testing.T) {
 	t.Parallel()
 
+	maxPrice := 2000.0
+	minCores := uint32(4)
+	minGhz := 2.2
 	filter := &proto.Filter{
-		MaxPriceUsd: 2000,
-		MinCpuCores: 4,
-		MinCpuGhz:   2.2,
+		MaxPriceUsd: &maxPrice,
+		MinCpuCores: &minCores,
+		MinCpuGhz:   &minGhz,
 		MinRam: &proto.Memory{
 			Value: 8,
 			Unit:  proto.Memory_GIGABYTE,
@@ -123,6 +130,153 @@ func TestClientSearchLaptop(t *testing.T) {
 	require.Equal(t, len(expectedIDs), found)
 }
 
+func TestClientSearchLaptopOrdered(t *testing.T) {
+	t.Parallel()
+
+	laptopRepo := repository.NewLaptopRepository()
+	prices := []float64{1500, 2500, 1800, 2500, 3000, 2100}
+	for _, price := range prices {
+		laptop := sample.NewLaptop()
+		laptop.PriceUsd = price
+		err := laptopRepo.Save(laptop)
+		require.NoError(t, err)
+	}
+
+	serverAddress := startTestLaptopService(t, laptopRepo, nil, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	search := func(req *proto.SearchLaptopRequest) []*proto.Laptop {
+		stream, err := laptopClient.SearchLaptop(context.Background(), req)
+		require.NoError(t, err)
+
+		var laptops []*proto.Laptop
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return laptops
+			}
+			require.NoError(t, err)
+			laptops = append(laptops, res.GetLaptop())
+		}
+	}
+
+	laptops := search(&proto.SearchLaptopRequest{OrderBy: "price desc", MaxResults: 3})
+	require.Len(t, laptops, 3)
+	require.Equal(t, 3000.0, laptops[0].GetPriceUsd())
+	require.Equal(t, 2500.0, laptops[1].GetPriceUsd())
+	require.Equal(t, 2500.0, laptops[2].GetPriceUsd())
+	require.Less(t, laptops[1].GetId(), laptops[2].GetId())
+
+	// the offset skips laptops of the ordered results
+	offset := search(&proto.SearchLaptopRequest{OrderBy: "price desc", MaxResults: 3, Offset: 2})
+	require.Len(t, offset, 3)
+	require.Equal(t, laptops[2].GetId(), offset[0].GetId())
+	require.Equal(t, 2100.0, offset[1].GetPriceUsd())
+	require.Equal(t, 1800.0, offset[2].GetPriceUsd())
+
+	require.Empty(t, search(&proto.SearchLaptopRequest{OrderBy: "price desc", Offset: 6}))
+
+	// only a text search ranks laptops by relevance
+	ranked := search(&proto.SearchLaptopRequest{Text: laptops[0].GetBrand(), OrderBy: "relevance desc"})
+	require.NotEmpty(t, ranked)
+	for _, laptop := range ranked {
+		require.Equal(t, laptops[0].GetBrand(), laptop.GetBrand())
+	}
+
+	stream, err := laptopClient.SearchLaptop(context.Background(), &proto.SearchLaptopRequest{OrderBy: "relevance desc, price"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestClientSearchLaptopWithRating(t *testing.T) {
+	t.Parallel()
+
+	laptopRepo := repository.NewLaptopRepository()
+	ratingRepo := repository.NewRatingRepository()
+	scores := map[string]float64{}
+	for _, score := range []float64{0, 4, 9} {
+		laptop := sample.NewLaptop()
+		require.NoError(t, laptopRepo.Save(laptop))
+		scores[laptop.GetId()] = score
+
+		if score > 0 {
+			_, err := ratingRepo.Rate(laptop.GetId(), "tester", score)
+			require.NoError(t, err)
+		}
+	}
+
+	serverAddress := startTestLaptopService(t, laptopRepo, nil, ratingRepo)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	for _, includeRating := range []bool{false, true} {
+		req := &proto.SearchLaptopRequest{IncludeRating: includeRating}
+		stream, err := laptopClient.SearchLaptop(context.Background(), req)
+		require.NoError(t, err)
+
+		found := 0
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			found++
+
+			if !includeRating {
+				require.Nil(t, res.GetRating())
+				continue
+			}
+			require.Equal(t, res.GetLaptop().GetId(), res.GetRating().GetLaptopId())
+			require.Equal(t, scores[res.GetLaptop().GetId()], res.GetRating().GetAverage())
+		}
+		require.Equal(t, len(scores), found)
+	}
+}
+
+func TestClientSearchLaptopQuery(t *testing.T) {
+	t.Parallel()
+
+	laptopRepo := repository.NewLaptopRepository()
+	expectedIDs := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		laptop := sample.NewLaptop()
+		laptop.Brand = []string{"Dell", "Lenovo", "Apple", "Dell"}[i]
+		laptop.PriceUsd = float64(1000 * (i + 1))
+		if laptop.Brand == "Dell" {
+			expectedIDs[laptop.Id] = true
+		}
+		err := laptopRepo.Save(laptop)
+		require.NoError(t, err)
+	}
+
+	serverAddress := startTestLaptopService(t, laptopRepo, nil, nil)
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	req := &proto.SearchLaptopRequest{Query: "brand = dell or (price > 1500 and not brand in (lenovo, apple))"}
+	stream, err := laptopClient.SearchLaptop(context.Background(), req)
+	require.NoError(t, err)
+
+	found := 0
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Contains(t, expectedIDs, res.GetLaptop().GetId())
+		found++
+	}
+	require.Equal(t, len(expectedIDs), found)
+
+	req = &proto.SearchLaptopRequest{Query: "brand = dell or"}
+	stream, err = laptopClient.SearchLaptop(context.Background(), req)
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestClientUploadImage(t *testing.T) {
 	t.Parallel()
 
@@ -202,20 +356,29 @@ func TestClientRateLaptop(t *testing.T) {
 	err := laptopRepo.Save(laptop)
 	require.NoError(t, err)
 
+	_, err = ratingRepo.Rate(laptop.GetId(), "bob", 6)
+	require.NoError(t, err)
+
 	serverAddress := startTestLaptopService(t, laptopRepo, nil, ratingRepo)
 	laptopClient := newTestLaptopClient(t, serverAddress)
 
-	stream, err := laptopClient.RateLaptop(context.Background())
+	stream, err := laptopClient.RateLaptop(withTestUser(context.Background(), "alice"))
 	require.NoError(t, err)
 
-	scores := []float64{8, 7.5, 10}
-	averages := []float64{8, 7.75, 8.5}
+	// failed ratings are reported one by one and leave the average alone, a
+	// new score of the user replaces the previous one
+	laptopIDs := []string{laptop.GetId(), laptop.GetId(), laptop.GetId(), "unknown", laptop.GetId(), laptop.GetId()}
+	scores := []float64{8, math.NaN(), 7, 9, 10, math.Inf(-1)}
+	expectedCodes := []codes.Code{codes.OK, codes.InvalidArgument, codes.OK, codes.NotFound, codes.OK, codes.InvalidArgument}
+	counts := []uint32{2, 0, 2, 0, 2, 0}
+	averages := []float64{7, 0, 6.5, 0, 8, 0}
 
 	n := len(scores)
 	for i := 0; i < n; i++ {
 		req := &proto.RateLaptopRequest{
-			LaptopId: laptop.GetId(),
-			Score:    scores[i],
+			LaptopId:  laptopIDs[i],
+			Score:     scores[i],
+			RequestId: fmt.Sprint(i),
 		}
 
 		err := stream.Send(req)
@@ -229,20 +392,67 @@ func TestClientRateLaptop(t *testing.T) {
 		res, err := stream.Recv()
 		if err == io.EOF {
 			require.Equal(t, n, idx)
-			return
+			break
 		}
 
 		require.NoError(t, err)
-		require.Equal(t, laptop.GetId(), res.GetLaptopId())
-		require.Equal(t, uint32(idx+1), res.GetRatedCount())
+		require.Equal(t, fmt.Sprint(idx), res.GetRequestId())
+		require.Equal(t, laptopIDs[idx], res.GetLaptopId())
+		require.Equal(t, counts[idx], res.GetRatedCount())
 		require.Equal(t, averages[idx], res.GetAverageScore())
+		require.Equal(t, expectedCodes[idx], status.FromProto(res.GetStatus()).Code())
 	}
+
+	res, err := laptopClient.RetractRating(withTestUser(context.Background(), "alice"), &proto.RetractRatingRequest{LaptopId: laptop.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), res.GetRating().GetCount())
+	require.Equal(t, 6.0, res.GetRating().GetAverage())
+
+	// a rating needs an authenticated user
+	anonymous, err := laptopClient.RateLaptop(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, anonymous.Send(&proto.RateLaptopRequest{LaptopId: laptop.GetId(), Score: 8}))
+
+	rated, err := anonymous.Recv()
+	require.NoError(t, err)
+	require.Equal(t, codes.Unauthenticated, status.FromProto(rated.GetStatus()).Code())
+}
+
+func TestClientUnknownLaptop(t *testing.T) {
+	t.Parallel()
+
+	serverAddress := startTestLaptopService(t, repository.NewLaptopRepository(), nil, repository.NewRatingRepository())
+	laptopClient := newTestLaptopClient(t, serverAddress)
+
+	upload, err := laptopClient.UploadImage(context.Background())
+	require.NoError(t, err)
+
+	err = upload.Send(&proto.UploadImageRequest{
+		Data: &proto.UploadImageRequest_Info{
+			Info: &proto.ImageInfo{LaptopId: "unknown", ImageType: ".jpg"},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = upload.CloseAndRecv()
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	rate, err := laptopClient.RateLaptop(withTestUser(context.Background(), "alice"))
+	require.NoError(t, err)
+	require.NoError(t, rate.Send(&proto.RateLaptopRequest{LaptopId: "unknown", Score: 8}))
+
+	res, err := rate.Recv()
+	require.NoError(t, err)
+	require.Equal(t, codes.NotFound, status.FromProto(res.GetStatus()).Code())
 }
 
 func startTestLaptopService(t *testing.T, laptopRepo repository.LaptopRepository, imageRepo repository.ImageRepository, ratingRepo repository.RatingRepository) string {
-	laptopServer := NewLaptopService(laptopRepo, imageRepo, ratingRepo)
+	laptopServer := NewLaptopService(laptopRepo, imageRepo, ratingRepo, repository.NewReviewRepository())
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(testUserUnaryInterceptor),
+		grpc.StreamInterceptor(testUserStreamInterceptor),
+	)
 	proto.RegisterLaptopServiceServer(grpcServer, laptopServer)
 
 	listener, err := net.Listen("tcp", ":0") // random available port
@@ -253,6 +463,40 @@ func startTestLaptopService(t *testing.T, laptopRepo repository.LaptopRepository
 	return listener.Addr().String()
 }
 
+// withTestUser authenticates the RPCs of the context as the user, in place
+// of the access token checked by the AuthMiddleware of the server.
+func withTestUser(ctx context.Context, username string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, testUserKey, username)
+}
+
+const testUserKey = "test-user"
+
+func testUserUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(testUserContext(ctx), req)
+}
+
+func testUserStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &testUserStream{ServerStream: ss, ctx: testUserContext(ss.Context())})
+}
+
+type testUserStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testUserStream) Context() context.Context {
+	return s.ctx
+}
+
+func testUserContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	usernames := md.Get(testUserKey)
+	if len(usernames) == 0 {
+		return ctx
+	}
+	return ContextWithUser(ctx, &UserClaims{Username: usernames[0], Role: "user"})
+}
+
 func newTestLaptopClient(t *testing.T, serverAddress string) proto.LaptopServiceClient {
 	conn, err := grpc.Dial(serverAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	require.NoError(t, err)
@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/stretchr/testify/require"
 	"gitlab.com/iruldev/grpc-class/engine/repository"
 	"gitlab.com/iruldev/grpc-class/proto"
 	"gitlab.com/iruldev/grpc-class/sample"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"testing"
+	"time"
 )
 
 func TestServiceCreateLaptop(t *testing.T) {
@@ -65,7 +69,7 @@ func TestServiceCreateLaptop(t *testing.T) {
 
 			req := &proto.CreateLaptopRequest{Laptop: tc.laptop}
 
-			service := NewLaptopService(tc.store, nil, nil)
+			service := NewLaptopService(tc.store, nil, nil, nil)
 			res, err := service.CreateLaptop(context.Background(), req)
 			if tc.code == codes.OK {
 				require.NoError(t, err)
@@ -84,3 +88,517 @@ func TestServiceCreateLaptop(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceUpdateLaptop(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewLaptopRepository()
+	laptop := sample.NewLaptop()
+	err := store.Save(laptop)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		laptop *proto.Laptop
+		paths  []string
+		code   codes.Code
+	}{
+		{
+			name:   "success_partial",
+			laptop: &proto.Laptop{Id: laptop.Id, PriceUsd: 999, Cpu: &proto.CPU{MinGhz: 1.5}},
+			paths:  []string{"price_usd", "cpu.min_ghz"},
+			code:   codes.OK,
+		},
+		{
+			name:   "failure_no_id",
+			laptop: &proto.Laptop{PriceUsd: 999},
+			paths:  []string{"price_usd"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "failure_update_id",
+			laptop: &proto.Laptop{Id: laptop.Id},
+			paths:  []string{"id"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "failure_unknown_path",
+			laptop: &proto.Laptop{Id: laptop.Id},
+			paths:  []string{"cpu.unknown"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "failure_invalid_ram",
+			laptop: &proto.Laptop{Id: laptop.Id, Ram: &proto.Memory{Unit: proto.Memory_GIGABYTE}},
+			paths:  []string{"ram"},
+			code:   codes.InvalidArgument,
+		},
+		{
+			name:   "failure_stale_version",
+			laptop: &proto.Laptop{Id: laptop.Id, PriceUsd: 999, Version: 99},
+			paths:  []string{"price_usd"},
+			code:   codes.Aborted,
+		},
+		{
+			name:   "failure_not_found",
+			laptop: &proto.Laptop{Id: sample.NewLaptop().Id, PriceUsd: 999},
+			paths:  []string{"price_usd"},
+			code:   codes.NotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			req := &proto.UpdateLaptopRequest{
+				Laptop:     tc.laptop,
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: tc.paths},
+			}
+
+			service := NewLaptopService(store, nil, nil, nil)
+			res, err := service.UpdateLaptop(context.Background(), req)
+			if tc.code != codes.OK {
+				require.Nil(t, res)
+				require.Equal(t, tc.code, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.laptop.PriceUsd, res.GetLaptop().GetPriceUsd())
+			require.Equal(t, tc.laptop.Cpu.MinGhz, res.GetLaptop().GetCpu().GetMinGhz())
+			require.Equal(t, laptop.Cpu.MaxGhz, res.GetLaptop().GetCpu().GetMaxGhz())
+			require.Equal(t, laptop.Name, res.GetLaptop().GetName())
+			require.Equal(t, uint64(2), res.GetLaptop().GetVersion())
+
+			other, err := store.Find(laptop.Id)
+			require.NoError(t, err)
+			require.Equal(t, tc.laptop.PriceUsd, other.GetPriceUsd())
+		})
+	}
+}
+
+func TestServiceUpdateLaptopInterleaved(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		// interleaved is the number of updates made between reading and
+		// writing the laptop
+		interleaved int
+		version     uint64
+		code        codes.Code
+	}{
+		{"merged_again", 1, 0, codes.OK},
+		{"gives_up", maxMergeAttempts, 0, codes.Aborted},
+		{"client_version", 1, 1, codes.Aborted},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			laptop := sample.NewLaptop()
+			store := &interleavingRepository{LaptopRepository: repository.NewLaptopRepository()}
+			require.NoError(t, store.Save(laptop))
+
+			// another admin changes the price while the name is merged
+			store.interleave = func(i int) {
+				if i >= tc.interleaved {
+					return
+				}
+				other, err := store.LaptopRepository.Find(laptop.Id)
+				require.NoError(t, err)
+				other.PriceUsd = float64(100 + i)
+				_, err = store.LaptopRepository.Update(other)
+				require.NoError(t, err)
+			}
+
+			req := &proto.UpdateLaptopRequest{
+				Laptop:     &proto.Laptop{Id: laptop.Id, Name: "renamed", Version: tc.version},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+			}
+			res, err := NewLaptopService(store, nil, nil, nil).UpdateLaptop(context.Background(), req)
+			require.Equal(t, tc.code, status.Code(err))
+
+			stored, err := store.Find(laptop.Id)
+			require.NoError(t, err)
+			require.Equal(t, float64(100+tc.interleaved-1), stored.GetPriceUsd())
+			if tc.code != codes.OK {
+				require.Equal(t, laptop.Name, stored.GetName())
+				return
+			}
+
+			require.Equal(t, "renamed", stored.GetName())
+			require.True(t, protobuf.Equal(stored, res.GetLaptop()))
+		})
+	}
+}
+
+// interleavingRepository calls interleave with the number of the update
+// before every update, to update the laptop between a read and a write.
+type interleavingRepository struct {
+	repository.LaptopRepository
+	interleave func(i int)
+	updates    int
+}
+
+func (r *interleavingRepository) Update(laptop *proto.Laptop) (*proto.Laptop, error) {
+	r.interleave(r.updates)
+	r.updates++
+	return r.LaptopRepository.Update(laptop)
+}
+
+func TestServiceGetAndDeleteLaptop(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewLaptopRepository()
+	laptop := sample.NewLaptop()
+	err := store.Save(laptop)
+	require.NoError(t, err)
+
+	service := NewLaptopService(store, nil, nil, nil)
+
+	res, err := service.GetLaptop(context.Background(), &proto.GetLaptopRequest{Id: laptop.Id})
+	require.NoError(t, err)
+	require.Equal(t, laptop.Id, res.GetLaptop().GetId())
+
+	require.Equal(t, uint64(1), res.GetLaptop().GetVersion())
+
+	_, err = service.DeleteLaptop(context.Background(), &proto.DeleteLaptopRequest{Id: laptop.Id, Version: 2})
+	require.Equal(t, codes.Aborted, status.Code(err))
+
+	_, err = service.DeleteLaptop(context.Background(), &proto.DeleteLaptopRequest{Id: laptop.Id, Version: 1})
+	require.NoError(t, err)
+
+	_, err = service.GetLaptop(context.Background(), &proto.GetLaptopRequest{Id: laptop.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = service.DeleteLaptop(context.Background(), &proto.DeleteLaptopRequest{Id: laptop.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestServiceListLaptops(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewLaptopRepository()
+	prices := make([]float64, 0, 7)
+	for i := 0; i < 7; i++ {
+		laptop := sample.NewLaptop()
+		laptop.PriceUsd = float64(1000 + i*100)
+		prices = append(prices, laptop.PriceUsd)
+		err := store.Save(laptop)
+		require.NoError(t, err)
+	}
+
+	service := NewLaptopService(store, nil, nil, nil)
+	req := &proto.ListLaptopsRequest{PageSize: 3, OrderBy: "price desc"}
+
+	res, err := service.ListLaptops(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, res.GetLaptops(), 3)
+	require.Equal(t, prices[6], res.GetLaptops()[0].GetPriceUsd())
+	require.NotEmpty(t, res.GetNextPageToken())
+
+	// a laptop inserted before the cursor must not shift the next page
+	laptop := sample.NewLaptop()
+	laptop.PriceUsd = 5000
+	err = store.Save(laptop)
+	require.NoError(t, err)
+
+	var got []float64
+	for len(req.PageToken) == 0 || len(res.GetNextPageToken()) > 0 {
+		req.PageToken = res.GetNextPageToken()
+		res, err = service.ListLaptops(context.Background(), req)
+		require.NoError(t, err)
+		for _, laptop := range res.GetLaptops() {
+			got = append(got, laptop.GetPriceUsd())
+		}
+	}
+	require.Equal(t, []float64{prices[3], prices[2], prices[1], prices[0]}, got)
+
+	req.PageToken = "x" + req.PageToken
+	_, err = service.ListLaptops(context.Background(), req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// servers sharing a page token key accept the tokens of each other
+	req.PageToken = ""
+	service.SetPageTokenKey([]byte("shared"))
+	res, err = service.ListLaptops(context.Background(), req)
+	require.NoError(t, err)
+
+	replica := NewLaptopService(store, nil, nil, nil)
+	replica.SetPageTokenKey([]byte("shared"))
+	req.PageToken = res.GetNextPageToken()
+	res, err = replica.ListLaptops(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, res.GetLaptops(), 3)
+	require.Equal(t, prices[4], res.GetLaptops()[0].GetPriceUsd())
+
+	replica.SetPageTokenKey([]byte("other"))
+	_, err = replica.ListLaptops(context.Background(), req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	req.PageToken = ""
+	req.OrderBy = "price sideways"
+	_, err = service.ListLaptops(context.Background(), req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// there is no search text to rank the laptops by
+	req.OrderBy = "relevance desc"
+	_, err = service.ListLaptops(context.Background(), req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServiceSearchFacets(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewLaptopRepository()
+	for i, brand := range []string{"Dell", "Dell", "Lenovo", "Apple"} {
+		laptop := sample.NewLaptop()
+		laptop.Brand = brand
+		laptop.PriceUsd = float64(1200 + 400*i)
+		laptop.Ram = &proto.Memory{Value: uint64(8 << i), Unit: proto.Memory_GIGABYTE}
+		laptop.Gpus = []*proto.GPU{sample.NewGPU(), sample.NewGPU()}
+		laptop.Gpus[0].Brand = "NVIDIA"
+		laptop.Gpus[1].Brand = "NVIDIA"
+		err := store.Save(laptop)
+		require.NoError(t, err)
+	}
+
+	service := NewLaptopService(store, nil, nil, nil)
+	maxPrice := 2200.0
+	req := &proto.SearchFacetsRequest{Filter: &proto.Filter{MaxPriceUsd: &maxPrice}}
+
+	res, err := service.SearchFacets(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), res.GetTotal())
+
+	buckets := make(map[string]map[string]uint32)
+	for _, facet := range res.GetFacets() {
+		buckets[facet.GetName()] = make(map[string]uint32)
+		for _, bucket := range facet.GetBuckets() {
+			buckets[facet.GetName()][bucket.GetValue()] = bucket.GetCount()
+		}
+	}
+
+	require.Equal(t, map[string]uint32{"Dell": 2, "Lenovo": 1}, buckets["brand"])
+	require.Equal(t, map[string]uint32{"NVIDIA": 3}, buckets["gpu_brand"])
+	require.Equal(t, map[string]uint32{"8-16GB": 1, "16-32GB": 1, "32-64GB": 1}, buckets["ram"])
+	require.Equal(t, map[string]uint32{"1000-1500": 1, "1500-2000": 1, "2000-2500": 1}, buckets["price"])
+
+	for _, r := range res.GetRanges() {
+		if r.GetName() == "price_usd" {
+			require.Equal(t, 1200.0, r.GetMin())
+			require.Equal(t, 2000.0, r.GetMax())
+		}
+	}
+
+	req.Query = "brand ="
+	_, err = service.SearchFacets(context.Background(), req)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServiceGetRatings(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewLaptopRepository()
+	ratings := repository.NewRatingRepository()
+
+	rated := sample.NewLaptop()
+	require.NoError(t, store.Save(rated))
+	unrated := sample.NewLaptop()
+	require.NoError(t, store.Save(unrated))
+
+	for i, score := range []float64{8, 9.5, 3, 8.5} {
+		_, err := ratings.Rate(rated.Id, fmt.Sprintf("user-%d", i), score)
+		require.NoError(t, err)
+	}
+
+	service := NewLaptopService(store, nil, ratings, nil)
+	expected := &proto.RatingSummary{
+		LaptopId: rated.Id,
+		Count:    4,
+		Average:  7.25,
+		Min:      3,
+		Max:      9.5,
+		Histogram: []*proto.ScoreBucket{
+			{Score: 3, Count: 1},
+			{Score: 8, Count: 2},
+			{Score: 9, Count: 1},
+		},
+	}
+
+	res, err := service.GetRating(context.Background(), &proto.GetRatingRequest{LaptopId: rated.Id})
+	require.NoError(t, err)
+	require.True(t, protobuf.Equal(expected, res.GetRating()))
+
+	res, err = service.GetRating(context.Background(), &proto.GetRatingRequest{LaptopId: unrated.Id})
+	require.NoError(t, err)
+	require.True(t, protobuf.Equal(&proto.RatingSummary{LaptopId: unrated.Id}, res.GetRating()))
+
+	_, err = service.GetRating(context.Background(), &proto.GetRatingRequest{LaptopId: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	req := &proto.GetRatingsRequest{LaptopIds: []string{unrated.Id, "unknown", rated.Id}}
+	all, err := service.GetRatings(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, all.GetRatings(), 2)
+	require.True(t, protobuf.Equal(&proto.RatingSummary{LaptopId: unrated.Id}, all.GetRatings()[0]))
+	require.True(t, protobuf.Equal(expected, all.GetRatings()[1]))
+}
+
+func TestServiceRetractRating(t *testing.T) {
+	t.Parallel()
+
+	ratings := repository.NewRatingRepository()
+	_, err := ratings.Rate("laptop-1", "alice", 2)
+	require.NoError(t, err)
+	_, err = ratings.Rate("laptop-1", "bob", 6)
+	require.NoError(t, err)
+
+	service := NewLaptopService(repository.NewLaptopRepository(), nil, ratings, nil)
+	req := &proto.RetractRatingRequest{LaptopId: "laptop-1"}
+
+	_, err = service.RetractRating(context.Background(), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := ContextWithUser(context.Background(), &UserClaims{Username: "alice", Role: "user"})
+	res, err := service.RetractRating(ctx, req)
+	require.NoError(t, err)
+	require.True(t, protobuf.Equal(&proto.RatingSummary{
+		LaptopId:  "laptop-1",
+		Count:     1,
+		Average:   6,
+		Min:       6,
+		Max:       6,
+		Histogram: []*proto.ScoreBucket{{Score: 6, Count: 1}},
+	}, res.GetRating()))
+
+	_, err = service.RetractRating(ctx, req)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	res, err = service.RetractRating(ContextWithUser(context.Background(), &UserClaims{Username: "bob", Role: "user"}), req)
+	require.NoError(t, err)
+	require.True(t, protobuf.Equal(&proto.RatingSummary{LaptopId: "laptop-1"}, res.GetRating()))
+}
+
+func TestServiceTopRatedLaptops(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewLaptopRepository()
+	ratings := repository.NewRatingRepository()
+
+	scores := [][]float64{{10}, {9, 9, 9, 9, 9, 9, 9, 9}, {7, 8}, {10, 10}}
+	laptops := make([]*proto.Laptop, len(scores))
+	for i, laptopScores := range scores {
+		laptops[i] = sample.NewLaptop()
+		laptops[i].PriceUsd = float64(1000 * (i + 1))
+		require.NoError(t, store.Save(laptops[i]))
+
+		for j, score := range laptopScores {
+			_, err := ratings.Rate(laptops[i].Id, fmt.Sprintf("user-%d", j), score)
+			require.NoError(t, err)
+		}
+	}
+	_, err := ratings.Rate("deleted", "user-0", 10)
+	require.NoError(t, err)
+
+	service := NewLaptopService(store, nil, ratings, nil)
+	maxPrice := 3500.0
+
+	testCases := []struct {
+		name     string
+		req      *proto.TopRatedLaptopsRequest
+		expected []*proto.Laptop
+	}{
+		{"all", &proto.TopRatedLaptopsRequest{}, []*proto.Laptop{laptops[1], laptops[3], laptops[0], laptops[2]}},
+		{"limit", &proto.TopRatedLaptopsRequest{Limit: 2}, []*proto.Laptop{laptops[1], laptops[3]}},
+		{"filter", &proto.TopRatedLaptopsRequest{Filter: &proto.Filter{MaxPriceUsd: &maxPrice}, Limit: 2}, []*proto.Laptop{laptops[1], laptops[0]}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := service.TopRatedLaptops(context.Background(), tc.req)
+			require.NoError(t, err)
+			require.Len(t, res.GetLaptops(), len(tc.expected))
+
+			for i, ranked := range res.GetLaptops() {
+				require.Equal(t, tc.expected[i].Id, ranked.GetLaptop().GetId())
+				require.Equal(t, ranked.GetLaptop().GetId(), ranked.GetRating().GetLaptopId())
+				require.Greater(t, ranked.GetRating().GetCount(), uint32(0))
+			}
+		})
+	}
+
+	res, err := service.TopRatedLaptops(context.Background(), &proto.TopRatedLaptopsRequest{Limit: 1})
+	require.NoError(t, err)
+	require.InDelta(t, (5*5.5+72)/13, res.GetLaptops()[0].GetScore(), 1e-9)
+}
+
+func TestServiceRankingWithConcurrentWrites(t *testing.T) {
+	t.Parallel()
+
+	store := repository.NewLaptopRepository()
+	ratings := repository.NewRatingRepository()
+	service := NewLaptopService(store, nil, ratings, nil)
+
+	laptops := make([]*proto.Laptop, 20)
+	for i := range laptops {
+		laptops[i] = sample.NewLaptop()
+		require.NoError(t, store.Save(laptops[i]))
+	}
+
+	// reads that order by rating and writes of both stores must not wait for
+	// each other in a cycle
+	const iterations = 200
+	calls := []func(i int) error{
+		func(i int) error {
+			_, err := service.ListLaptops(context.Background(), &proto.ListLaptopsRequest{OrderBy: "rating desc"})
+			return err
+		},
+		func(i int) error {
+			_, err := service.TopRatedLaptops(context.Background(), &proto.TopRatedLaptopsRequest{})
+			return err
+		},
+		func(i int) error {
+			return store.Save(sample.NewLaptop())
+		},
+		func(i int) error {
+			_, err := ratings.Rate(laptops[i%len(laptops)].Id, fmt.Sprintf("user-%d", i), 7)
+			return err
+		},
+	}
+
+	const workers = 4
+	done := make(chan error, workers*len(calls))
+	for w := 0; w < workers; w++ {
+		for _, call := range calls {
+			go func(call func(i int) error) {
+				for i := 0; i < iterations; i++ {
+					if err := call(i); err != nil {
+						done <- err
+						return
+					}
+				}
+				done <- nil
+			}(call)
+		}
+	}
+
+	timeout := time.After(10 * time.Second)
+	for i := 0; i < workers*len(calls); i++ {
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-timeout:
+			t.Fatal("ranking reads and writes are deadlocked")
+		}
+	}
+}
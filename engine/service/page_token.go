@@ -0,0 +1,74 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// pageToken marks the position of the last laptop returned in a page. It
+// holds the sort values of that laptop, so the next page starts right after
+// it even if laptops are inserted or removed in between.
+type pageToken struct {
+	OrderBy string        `json:"o"`
+	Values  []interface{} `json:"v"`
+}
+
+// pageTokenSigner turns page tokens into opaque strings and rejects strings
+// that were not produced by it.
+type pageTokenSigner struct {
+	key []byte
+}
+
+// newPageTokenSigner signs with the key, or with a random key if it is empty.
+func newPageTokenSigner(key []byte) *pageTokenSigner {
+	if len(key) > 0 {
+		return &pageTokenSigner{key: key}
+	}
+
+	key = make([]byte, sha256.Size)
+	_, err := rand.Read(key)
+	if err != nil {
+		log.Fatal("cannot generate page token key: ", err)
+	}
+	return &pageTokenSigner{key: key}
+}
+
+func (s *pageTokenSigner) encode(token *pageToken) (string, error) {
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", fmt.Errorf("cannot marshal page token: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+
+	return base64.RawURLEncoding.EncodeToString(append(mac.Sum(nil), payload...)), nil
+}
+
+func (s *pageTokenSigner) decode(value string) (*pageToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) < sha256.Size {
+		return nil, errors.New("malformed page token")
+	}
+
+	signature, payload := data[:sha256.Size], data[sha256.Size:]
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("page token signature mismatch")
+	}
+
+	token := &pageToken{}
+	err = json.Unmarshal(payload, token)
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal page token: %w", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// RatingScale is the range of scores that RateLaptop accepts.
+type RatingScale struct {
+	Min float64
+	Max float64
+	// Step is the granularity of the scores counted from Min, such as 0.5
+	// for half-steps. Zero accepts any score in the range.
+	Step float64
+}
+
+// DefaultRatingScale accepts any score from 1 to 10.
+var DefaultRatingScale = RatingScale{Min: 1, Max: 10}
+
+// Check returns an error describing why the score is not on the scale.
+func (s RatingScale) Check(score float64) error {
+	if math.IsNaN(score) || score < s.Min || score > s.Max {
+		return fmt.Errorf("must be between %s and %s", formatScore(s.Min), formatScore(s.Max))
+	}
+
+	if s.Step > 0 {
+		steps := (score - s.Min) / s.Step
+		// tolerate the rounding of scores such as 0.1 steps
+		if math.Abs(steps-math.Round(steps)) > 1e-9 {
+			return fmt.Errorf("must be a multiple of %s from %s", formatScore(s.Step), formatScore(s.Min))
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the scale accepts at least one score.
+func (s RatingScale) Validate() error {
+	switch {
+	case math.IsNaN(s.Min) || math.IsNaN(s.Max) || math.IsInf(s.Min, 0) || math.IsInf(s.Max, 0):
+		return fmt.Errorf("rating scale must be finite")
+	case s.Min > s.Max:
+		return fmt.Errorf("rating scale minimum %s is greater than the maximum %s", formatScore(s.Min), formatScore(s.Max))
+	case s.Step < 0 || math.IsNaN(s.Step):
+		return fmt.Errorf("rating scale step must not be negative")
+	}
+	return nil
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
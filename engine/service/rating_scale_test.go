@@ -0,0 +1,59 @@
+package service
+
+import (
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestRatingScaleCheck(t *testing.T) {
+	t.Parallel()
+
+	halfSteps := RatingScale{Min: 1, Max: 10, Step: 0.5}
+	tenths := RatingScale{Min: 0, Max: 1, Step: 0.1}
+
+	testCases := []struct {
+		name  string
+		scale RatingScale
+		score float64
+		valid bool
+	}{
+		{"min", DefaultRatingScale, 1, true},
+		{"max", DefaultRatingScale, 10, true},
+		{"any_step", DefaultRatingScale, 7.25, true},
+		{"below_min", DefaultRatingScale, 0.5, false},
+		{"negative", DefaultRatingScale, -3, false},
+		{"above_max", DefaultRatingScale, 10.5, false},
+		{"nan", DefaultRatingScale, math.NaN(), false},
+		{"inf", DefaultRatingScale, math.Inf(1), false},
+		{"half_step", halfSteps, 7.5, true},
+		{"not_half_step", halfSteps, 7.25, false},
+		{"rounded_step", tenths, 0.3, true},
+		{"not_rounded_step", tenths, 0.35, false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.scale.Check(tc.score)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRatingScaleValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, DefaultRatingScale.Validate())
+	require.NoError(t, RatingScale{Min: 1, Max: 5, Step: 1}.Validate())
+	require.Error(t, RatingScale{Min: 10, Max: 1}.Validate())
+	require.Error(t, RatingScale{Min: 1, Max: 10, Step: -1}.Validate())
+	require.Error(t, RatingScale{Min: 1, Max: math.Inf(1)}.Validate())
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type userKey struct{}
+
+// ContextWithUser returns a context that carries the claims of the
+// authenticated user.
+func ContextWithUser(ctx context.Context, claims *UserClaims) context.Context {
+	return context.WithValue(ctx, userKey{}, claims)
+}
+
+// UserFromContext returns the claims of the authenticated user, or false if
+// the RPC is not authenticated.
+func UserFromContext(ctx context.Context) (*UserClaims, bool) {
+	claims, ok := ctx.Value(userKey{}).(*UserClaims)
+	return claims, ok && claims != nil
+}
+
+// requireUsername returns the name of the authenticated user, or an
+// Unauthenticated status.
+func requireUsername(ctx context.Context) (string, error) {
+	claims, ok := UserFromContext(ctx)
+	if !ok || len(claims.Username) == 0 {
+		return "", status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+	return claims.Username, nil
+}
+
+// adminRole is the role of the users who moderate the reviews.
+const adminRole = "admin"
+
+// isAdmin reports whether the authenticated user is an admin.
+func isAdmin(ctx context.Context) bool {
+	claims, ok := UserFromContext(ctx)
+	return ok && claims.Role == adminRole
+}
+
+// caller names the user of an RPC in the logs.
+func caller(ctx context.Context) string {
+	claims, ok := UserFromContext(ctx)
+	if !ok {
+		return "anonymous"
+	}
+	return fmt.Sprintf("%s (%s)", claims.Username, claims.Role)
+}
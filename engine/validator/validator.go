@@ -0,0 +1,245 @@
+// Package validator checks messages against the FieldRules options declared
+// on their fields in the .proto files.
+package validator
+
+import (
+	"fmt"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"math"
+	"regexp"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validate returns nil if msg and its nested messages follow the rules of
+// their fields, or an InvalidArgument status with a BadRequest detail that
+// lists every invalid field.
+func Validate(msg protobuf.Message) error {
+	return ValidateAt("", msg)
+}
+
+// ValidateAt validates msg like Validate, naming the invalid fields from the
+// given path, such as "laptop" for a laptop embedded in a request.
+func ValidateAt(path string, msg protobuf.Message) error {
+	var violations Violations
+	violations.message(path, msg.ProtoReflect())
+	return violations.Err("invalid " + name(path, msg))
+}
+
+func name(path string, msg protobuf.Message) string {
+	if len(path) > 0 {
+		return path
+	}
+	return string(msg.ProtoReflect().Descriptor().Name())
+}
+
+// Violations collects the invalid fields of a request, each field is named
+// by its path from the request message such as "laptop.cpu.max_ghz" or
+// "laptop.gpus[0].memory.unit".
+type Violations []*errdetails.BadRequest_FieldViolation
+
+// Add reports an invalid field.
+func (v *Violations) Add(field, description string) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+}
+
+// Err returns nil if no field is invalid, or an InvalidArgument status with
+// the violations in a BadRequest detail.
+func (v Violations) Err(message string) error {
+	if len(v) == 0 {
+		return nil
+	}
+
+	st := status.Newf(codes.InvalidArgument, "%s: %s %s", message, v[0].Field, v[0].Description)
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func (v *Violations) message(path string, msg protoreflect.Message) {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		rules := fieldRules(field)
+		if rules.GetSkip() {
+			continue
+		}
+
+		fieldPath := string(field.Name())
+		if len(path) > 0 {
+			fieldPath = path + "." + fieldPath
+		}
+
+		switch {
+		case field.IsList():
+			v.list(fieldPath, msg, field, rules)
+		case field.IsMap():
+			// no request has a map yet
+		case field.HasPresence() && !msg.Has(field):
+			// unset messages, oneof members and optional scalars
+			if rules.GetRequired() {
+				v.Add(fieldPath, "is required")
+			}
+		case field.Message() != nil:
+			v.message(fieldPath, msg.Get(field).Message())
+		default:
+			v.scalar(fieldPath, msg, field, msg.Get(field), rules)
+		}
+	}
+}
+
+func (v *Violations) list(path string, msg protoreflect.Message, field protoreflect.FieldDescriptor, rules *proto.FieldRules) {
+	list := msg.Get(field).List()
+
+	switch {
+	case rules.GetRequired() && list.Len() == 0:
+		v.Add(path, "is required")
+	case list.Len() < int(rules.GetMinItems()):
+		v.Add(path, fmt.Sprintf("must have at least %d items", rules.GetMinItems()))
+	case rules != nil && rules.MaxItems != nil && list.Len() > int(rules.GetMaxItems()):
+		v.Add(path, fmt.Sprintf("must have at most %d items", rules.GetMaxItems()))
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if field.Message() != nil {
+			v.message(itemPath, list.Get(i).Message())
+		} else {
+			v.scalar(itemPath, msg, field, list.Get(i), rules)
+		}
+	}
+}
+
+func (v *Violations) scalar(path string, msg protoreflect.Message, field protoreflect.FieldDescriptor, value protoreflect.Value, rules *proto.FieldRules) {
+	if rules == nil {
+		return
+	}
+
+	if rules.GetRequired() && !field.IsList() && value.Equal(field.Default()) {
+		v.Add(path, "is required")
+		return
+	}
+
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		v.string(path, value.String(), rules)
+	case protoreflect.EnumKind:
+		if rules.GetDefined() {
+			number := value.Enum()
+			if number == 0 || field.Enum().Values().ByNumber(number) == nil {
+				v.Add(path, "must be specified")
+			}
+		}
+	default:
+		number, ok := toFloat(value)
+		if ok {
+			v.number(path, number, msg, rules)
+		}
+	}
+}
+
+func (v *Violations) string(path string, value string, rules *proto.FieldRules) {
+	length := utf8.RuneCountInString(value)
+	switch {
+	case length < int(rules.GetMinLen()):
+		v.Add(path, fmt.Sprintf("must have at least %d characters", rules.GetMinLen()))
+	case rules.MaxLen != nil && length > int(rules.GetMaxLen()):
+		v.Add(path, fmt.Sprintf("must have at most %d characters", rules.GetMaxLen()))
+	}
+
+	if len(rules.GetPattern()) > 0 {
+		pattern, err := compile(rules.GetPattern())
+		if err != nil {
+			v.Add(path, fmt.Sprintf("has an invalid pattern: %v", err))
+		} else if !pattern.MatchString(value) {
+			v.Add(path, fmt.Sprintf("must match %s", rules.GetPattern()))
+		}
+	}
+}
+
+func (v *Violations) number(path string, value float64, msg protoreflect.Message, rules *proto.FieldRules) {
+	// NaN compares false with every bound
+	if math.IsNaN(value) {
+		if rules.Gt != nil || rules.Gte != nil || rules.Lt != nil || rules.Lte != nil || len(rules.GetGteField()) > 0 {
+			v.Add(path, "must be a number")
+		}
+		return
+	}
+
+	switch {
+	case rules.Gt != nil && value <= rules.GetGt():
+		v.Add(path, "must be greater than "+formatFloat(rules.GetGt()))
+	case rules.Gte != nil && value < rules.GetGte():
+		v.Add(path, "must not be less than "+formatFloat(rules.GetGte()))
+	case rules.Lt != nil && value >= rules.GetLt():
+		v.Add(path, "must be less than "+formatFloat(rules.GetLt()))
+	case rules.Lte != nil && value > rules.GetLte():
+		v.Add(path, "must not be greater than "+formatFloat(rules.GetLte()))
+	}
+
+	if len(rules.GetGteField()) > 0 {
+		other := msg.Descriptor().Fields().ByName(protoreflect.Name(rules.GetGteField()))
+		if other == nil {
+			v.Add(path, fmt.Sprintf("is compared with unknown field %s", rules.GetGteField()))
+			return
+		}
+
+		bound, ok := toFloat(msg.Get(other))
+		if ok && value < bound {
+			v.Add(path, "must not be less than "+rules.GetGteField())
+		}
+	}
+}
+
+func fieldRules(field protoreflect.FieldDescriptor) *proto.FieldRules {
+	rules, _ := protobuf.GetExtension(field.Options(), proto.E_Rules).(*proto.FieldRules)
+	return rules
+}
+
+func toFloat(value protoreflect.Value) (float64, bool) {
+	switch v := value.Interface().(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var patterns sync.Map
+
+// compile returns the compiled pattern, patterns are compiled once as they
+// come from the descriptors.
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
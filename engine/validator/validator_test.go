@@ -0,0 +1,138 @@
+package validator
+
+import (
+	"github.com/stretchr/testify/require"
+	"gitlab.com/iruldev/grpc-class/proto"
+	"gitlab.com/iruldev/grpc-class/sample"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidateLaptop(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		modify func(laptop *proto.Laptop)
+		fields []string
+	}{
+		{"valid", func(laptop *proto.Laptop) {}, nil},
+		{"no_screen", func(laptop *proto.Laptop) { laptop.Screen = nil }, nil},
+		{"no_brand", func(laptop *proto.Laptop) { laptop.Brand = "" }, []string{"laptop.brand"}},
+		{"no_cpu", func(laptop *proto.Laptop) { laptop.Cpu = nil }, []string{"laptop.cpu"}},
+		{"max_below_min", func(laptop *proto.Laptop) {
+			laptop.Cpu.MaxGhz = laptop.Cpu.MinGhz - 0.5
+		}, []string{"laptop.cpu.max_ghz"}},
+		{"threads_below_cores", func(laptop *proto.Laptop) {
+			laptop.Cpu.NumberThreads = laptop.Cpu.NumberCores - 1
+		}, []string{"laptop.cpu.number_threads"}},
+		{"zero_ram", func(laptop *proto.Laptop) { laptop.Ram.Value = 0 }, []string{"laptop.ram.value"}},
+		{"negative_price", func(laptop *proto.Laptop) { laptop.PriceUsd = -1 }, []string{"laptop.price_usd"}},
+		{"nan_price", func(laptop *proto.Laptop) { laptop.PriceUsd = math.NaN() }, []string{"laptop.price_usd"}},
+		{"negative_weight", func(laptop *proto.Laptop) {
+			laptop.Weight = &proto.Laptop_WeightLb{WeightLb: -2}
+		}, []string{"laptop.weight_lb"}},
+		{"too_many_gpus", func(laptop *proto.Laptop) {
+			for len(laptop.Gpus) <= 8 {
+				laptop.Gpus = append(laptop.Gpus, sample.NewGPU())
+			}
+		}, []string{"laptop.gpus"}},
+		{"nested", func(laptop *proto.Laptop) {
+			laptop.Gpus = append(laptop.Gpus, &proto.GPU{Brand: "NVIDIA", Name: "RTX", MinGhz: 1, MaxGhz: 2})
+			laptop.Storages[0].Memory.Unit = proto.Memory_UNKNOWN
+			laptop.Screen.Resolution = &proto.Screen_Resolution{Width: 1920}
+		}, []string{
+			"laptop.gpus[1].memory",
+			"laptop.storages[0].memory.unit",
+			"laptop.screen.resolution.height",
+		}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			laptop := sample.NewLaptop()
+			tc.modify(laptop)
+
+			err := Validate(&proto.CreateLaptopRequest{Laptop: laptop})
+			requireViolations(t, err, tc.fields)
+
+			// the same rules apply to a laptop validated on its own
+			err = ValidateAt("laptop", laptop)
+			requireViolations(t, err, tc.fields)
+		})
+	}
+}
+
+func TestValidateRequests(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		req    protobuf.Message
+		fields []string
+	}{
+		{"no_laptop", &proto.CreateLaptopRequest{}, []string{"laptop"}},
+		{"partial_update", &proto.UpdateLaptopRequest{Laptop: &proto.Laptop{PriceUsd: 999}}, nil},
+		{"partial_filter", &proto.SearchLaptopRequest{Filter: &proto.Filter{
+			MinRam:        &proto.Memory{Value: 8},
+			MinResolution: &proto.Screen_Resolution{Width: 1920},
+		}}, nil},
+		{"valid_image_info", &proto.UploadImageRequest{Data: &proto.UploadImageRequest_Info{
+			Info: &proto.ImageInfo{LaptopId: "id", ImageType: ".jpg"},
+		}}, nil},
+		{"image_chunk", &proto.UploadImageRequest{Data: &proto.UploadImageRequest_ChunkData{
+			ChunkData: []byte{1, 2, 3},
+		}}, nil},
+		{"image_type_path", &proto.UploadImageRequest{Data: &proto.UploadImageRequest_Info{
+			Info: &proto.ImageInfo{LaptopId: "id", ImageType: "/../../etc"},
+		}}, []string{"info.image_type"}},
+		{"no_password", &proto.LoginRequest{Username: "admin"}, []string{"password"}},
+		{"long_review_title", &proto.CreateReviewRequest{
+			LaptopId: "id",
+			Title:    strings.Repeat("é", 201),
+		}, []string{"title"}},
+		{"review_title_at_limit", &proto.CreateReviewRequest{
+			LaptopId: "id",
+			Title:    strings.Repeat("é", 200),
+		}, nil},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			requireViolations(t, Validate(tc.req), tc.fields)
+		})
+	}
+}
+
+func requireViolations(t *testing.T, err error, fields []string) {
+	if len(fields) == 0 {
+		require.NoError(t, err)
+		return
+	}
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+
+	var violated []string
+	for _, violation := range badRequest.GetFieldViolations() {
+		violated = append(violated, violation.GetField())
+	}
+	require.Equal(t, fields, violated)
+}
@@ -19,10 +19,24 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	LaptopService_CreateLaptop_FullMethodName = "/grpc.class.LaptopService/CreateLaptop"
-	LaptopService_SearchLaptop_FullMethodName = "/grpc.class.LaptopService/SearchLaptop"
-	LaptopService_UploadImage_FullMethodName  = "/grpc.class.LaptopService/UploadImage"
-	LaptopService_RateLaptop_FullMethodName   = "/grpc.class.LaptopService/RateLaptop"
+	LaptopService_CreateLaptop_FullMethodName    = "/grpc.class.LaptopService/CreateLaptop"
+	LaptopService_GetLaptop_FullMethodName       = "/grpc.class.LaptopService/GetLaptop"
+	LaptopService_UpdateLaptop_FullMethodName    = "/grpc.class.LaptopService/UpdateLaptop"
+	LaptopService_DeleteLaptop_FullMethodName    = "/grpc.class.LaptopService/DeleteLaptop"
+	LaptopService_ListLaptops_FullMethodName     = "/grpc.class.LaptopService/ListLaptops"
+	LaptopService_SearchLaptop_FullMethodName    = "/grpc.class.LaptopService/SearchLaptop"
+	LaptopService_SearchFacets_FullMethodName    = "/grpc.class.LaptopService/SearchFacets"
+	LaptopService_UploadImage_FullMethodName     = "/grpc.class.LaptopService/UploadImage"
+	LaptopService_RateLaptop_FullMethodName      = "/grpc.class.LaptopService/RateLaptop"
+	LaptopService_RetractRating_FullMethodName   = "/grpc.class.LaptopService/RetractRating"
+	LaptopService_GetRating_FullMethodName       = "/grpc.class.LaptopService/GetRating"
+	LaptopService_GetRatings_FullMethodName      = "/grpc.class.LaptopService/GetRatings"
+	LaptopService_TopRatedLaptops_FullMethodName = "/grpc.class.LaptopService/TopRatedLaptops"
+	LaptopService_CreateReview_FullMethodName    = "/grpc.class.LaptopService/CreateReview"
+	LaptopService_EditReview_FullMethodName      = "/grpc.class.LaptopService/EditReview"
+	LaptopService_DeleteReview_FullMethodName    = "/grpc.class.LaptopService/DeleteReview"
+	LaptopService_ListReviews_FullMethodName     = "/grpc.class.LaptopService/ListReviews"
+	LaptopService_HideReview_FullMethodName      = "/grpc.class.LaptopService/HideReview"
 )
 
 // LaptopServiceClient is the client API for LaptopService service.
@@ -30,9 +44,23 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LaptopServiceClient interface {
 	CreateLaptop(ctx context.Context, in *CreateLaptopRequest, opts ...grpc.CallOption) (*CreateLaptopResponse, error)
+	GetLaptop(ctx context.Context, in *GetLaptopRequest, opts ...grpc.CallOption) (*GetLaptopResponse, error)
+	UpdateLaptop(ctx context.Context, in *UpdateLaptopRequest, opts ...grpc.CallOption) (*UpdateLaptopResponse, error)
+	DeleteLaptop(ctx context.Context, in *DeleteLaptopRequest, opts ...grpc.CallOption) (*DeleteLaptopResponse, error)
+	ListLaptops(ctx context.Context, in *ListLaptopsRequest, opts ...grpc.CallOption) (*ListLaptopsResponse, error)
 	SearchLaptop(ctx context.Context, in *SearchLaptopRequest, opts ...grpc.CallOption) (LaptopService_SearchLaptopClient, error)
+	SearchFacets(ctx context.Context, in *SearchFacetsRequest, opts ...grpc.CallOption) (*SearchFacetsResponse, error)
 	UploadImage(ctx context.Context, opts ...grpc.CallOption) (LaptopService_UploadImageClient, error)
 	RateLaptop(ctx context.Context, opts ...grpc.CallOption) (LaptopService_RateLaptopClient, error)
+	RetractRating(ctx context.Context, in *RetractRatingRequest, opts ...grpc.CallOption) (*RetractRatingResponse, error)
+	GetRating(ctx context.Context, in *GetRatingRequest, opts ...grpc.CallOption) (*GetRatingResponse, error)
+	GetRatings(ctx context.Context, in *GetRatingsRequest, opts ...grpc.CallOption) (*GetRatingsResponse, error)
+	TopRatedLaptops(ctx context.Context, in *TopRatedLaptopsRequest, opts ...grpc.CallOption) (*TopRatedLaptopsResponse, error)
+	CreateReview(ctx context.Context, in *CreateReviewRequest, opts ...grpc.CallOption) (*CreateReviewResponse, error)
+	EditReview(ctx context.Context, in *EditReviewRequest, opts ...grpc.CallOption) (*EditReviewResponse, error)
+	DeleteReview(ctx context.Context, in *DeleteReviewRequest, opts ...grpc.CallOption) (*DeleteReviewResponse, error)
+	ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*ListReviewsResponse, error)
+	HideReview(ctx context.Context, in *HideReviewRequest, opts ...grpc.CallOption) (*HideReviewResponse, error)
 }
 
 type laptopServiceClient struct {
@@ -52,6 +80,42 @@ func (c *laptopServiceClient) CreateLaptop(ctx context.Context, in *CreateLaptop
 	return out, nil
 }
 
+func (c *laptopServiceClient) GetLaptop(ctx context.Context, in *GetLaptopRequest, opts ...grpc.CallOption) (*GetLaptopResponse, error) {
+	out := new(GetLaptopResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetLaptop_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) UpdateLaptop(ctx context.Context, in *UpdateLaptopRequest, opts ...grpc.CallOption) (*UpdateLaptopResponse, error) {
+	out := new(UpdateLaptopResponse)
+	err := c.cc.Invoke(ctx, LaptopService_UpdateLaptop_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) DeleteLaptop(ctx context.Context, in *DeleteLaptopRequest, opts ...grpc.CallOption) (*DeleteLaptopResponse, error) {
+	out := new(DeleteLaptopResponse)
+	err := c.cc.Invoke(ctx, LaptopService_DeleteLaptop_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) ListLaptops(ctx context.Context, in *ListLaptopsRequest, opts ...grpc.CallOption) (*ListLaptopsResponse, error) {
+	out := new(ListLaptopsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ListLaptops_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *laptopServiceClient) SearchLaptop(ctx context.Context, in *SearchLaptopRequest, opts ...grpc.CallOption) (LaptopService_SearchLaptopClient, error) {
 	stream, err := c.cc.NewStream(ctx, &LaptopService_ServiceDesc.Streams[0], LaptopService_SearchLaptop_FullMethodName, opts...)
 	if err != nil {
@@ -84,6 +148,15 @@ func (x *laptopServiceSearchLaptopClient) Recv() (*SearchLaptopResponse, error)
 	return m, nil
 }
 
+func (c *laptopServiceClient) SearchFacets(ctx context.Context, in *SearchFacetsRequest, opts ...grpc.CallOption) (*SearchFacetsResponse, error) {
+	out := new(SearchFacetsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_SearchFacets_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *laptopServiceClient) UploadImage(ctx context.Context, opts ...grpc.CallOption) (LaptopService_UploadImageClient, error) {
 	stream, err := c.cc.NewStream(ctx, &LaptopService_ServiceDesc.Streams[1], LaptopService_UploadImage_FullMethodName, opts...)
 	if err != nil {
@@ -149,14 +222,109 @@ func (x *laptopServiceRateLaptopClient) Recv() (*RateLaptopResponse, error) {
 	return m, nil
 }
 
+func (c *laptopServiceClient) RetractRating(ctx context.Context, in *RetractRatingRequest, opts ...grpc.CallOption) (*RetractRatingResponse, error) {
+	out := new(RetractRatingResponse)
+	err := c.cc.Invoke(ctx, LaptopService_RetractRating_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) GetRating(ctx context.Context, in *GetRatingRequest, opts ...grpc.CallOption) (*GetRatingResponse, error) {
+	out := new(GetRatingResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetRating_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) GetRatings(ctx context.Context, in *GetRatingsRequest, opts ...grpc.CallOption) (*GetRatingsResponse, error) {
+	out := new(GetRatingsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_GetRatings_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) TopRatedLaptops(ctx context.Context, in *TopRatedLaptopsRequest, opts ...grpc.CallOption) (*TopRatedLaptopsResponse, error) {
+	out := new(TopRatedLaptopsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_TopRatedLaptops_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) CreateReview(ctx context.Context, in *CreateReviewRequest, opts ...grpc.CallOption) (*CreateReviewResponse, error) {
+	out := new(CreateReviewResponse)
+	err := c.cc.Invoke(ctx, LaptopService_CreateReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) EditReview(ctx context.Context, in *EditReviewRequest, opts ...grpc.CallOption) (*EditReviewResponse, error) {
+	out := new(EditReviewResponse)
+	err := c.cc.Invoke(ctx, LaptopService_EditReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) DeleteReview(ctx context.Context, in *DeleteReviewRequest, opts ...grpc.CallOption) (*DeleteReviewResponse, error) {
+	out := new(DeleteReviewResponse)
+	err := c.cc.Invoke(ctx, LaptopService_DeleteReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) ListReviews(ctx context.Context, in *ListReviewsRequest, opts ...grpc.CallOption) (*ListReviewsResponse, error) {
+	out := new(ListReviewsResponse)
+	err := c.cc.Invoke(ctx, LaptopService_ListReviews_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laptopServiceClient) HideReview(ctx context.Context, in *HideReviewRequest, opts ...grpc.CallOption) (*HideReviewResponse, error) {
+	out := new(HideReviewResponse)
+	err := c.cc.Invoke(ctx, LaptopService_HideReview_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LaptopServiceServer is the server API for LaptopService service.
 // All implementations must embed UnimplementedLaptopServiceServer
 // for forward compatibility
 type LaptopServiceServer interface {
 	CreateLaptop(context.Context, *CreateLaptopRequest) (*CreateLaptopResponse, error)
+	GetLaptop(context.Context, *GetLaptopRequest) (*GetLaptopResponse, error)
+	UpdateLaptop(context.Context, *UpdateLaptopRequest) (*UpdateLaptopResponse, error)
+	DeleteLaptop(context.Context, *DeleteLaptopRequest) (*DeleteLaptopResponse, error)
+	ListLaptops(context.Context, *ListLaptopsRequest) (*ListLaptopsResponse, error)
 	SearchLaptop(*SearchLaptopRequest, LaptopService_SearchLaptopServer) error
+	SearchFacets(context.Context, *SearchFacetsRequest) (*SearchFacetsResponse, error)
 	UploadImage(LaptopService_UploadImageServer) error
 	RateLaptop(LaptopService_RateLaptopServer) error
+	RetractRating(context.Context, *RetractRatingRequest) (*RetractRatingResponse, error)
+	GetRating(context.Context, *GetRatingRequest) (*GetRatingResponse, error)
+	GetRatings(context.Context, *GetRatingsRequest) (*GetRatingsResponse, error)
+	TopRatedLaptops(context.Context, *TopRatedLaptopsRequest) (*TopRatedLaptopsResponse, error)
+	CreateReview(context.Context, *CreateReviewRequest) (*CreateReviewResponse, error)
+	EditReview(context.Context, *EditReviewRequest) (*EditReviewResponse, error)
+	DeleteReview(context.Context, *DeleteReviewRequest) (*DeleteReviewResponse, error)
+	ListReviews(context.Context, *ListReviewsRequest) (*ListReviewsResponse, error)
+	HideReview(context.Context, *HideReviewRequest) (*HideReviewResponse, error)
 	mustEmbedUnimplementedLaptopServiceServer()
 }
 
@@ -167,15 +335,57 @@ type UnimplementedLaptopServiceServer struct {
 func (UnimplementedLaptopServiceServer) CreateLaptop(context.Context, *CreateLaptopRequest) (*CreateLaptopResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateLaptop not implemented")
 }
+func (UnimplementedLaptopServiceServer) GetLaptop(context.Context, *GetLaptopRequest) (*GetLaptopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLaptop not implemented")
+}
+func (UnimplementedLaptopServiceServer) UpdateLaptop(context.Context, *UpdateLaptopRequest) (*UpdateLaptopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateLaptop not implemented")
+}
+func (UnimplementedLaptopServiceServer) DeleteLaptop(context.Context, *DeleteLaptopRequest) (*DeleteLaptopResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteLaptop not implemented")
+}
+func (UnimplementedLaptopServiceServer) ListLaptops(context.Context, *ListLaptopsRequest) (*ListLaptopsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLaptops not implemented")
+}
 func (UnimplementedLaptopServiceServer) SearchLaptop(*SearchLaptopRequest, LaptopService_SearchLaptopServer) error {
 	return status.Errorf(codes.Unimplemented, "method SearchLaptop not implemented")
 }
+func (UnimplementedLaptopServiceServer) SearchFacets(context.Context, *SearchFacetsRequest) (*SearchFacetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchFacets not implemented")
+}
 func (UnimplementedLaptopServiceServer) UploadImage(LaptopService_UploadImageServer) error {
 	return status.Errorf(codes.Unimplemented, "method UploadImage not implemented")
 }
 func (UnimplementedLaptopServiceServer) RateLaptop(LaptopService_RateLaptopServer) error {
 	return status.Errorf(codes.Unimplemented, "method RateLaptop not implemented")
 }
+func (UnimplementedLaptopServiceServer) RetractRating(context.Context, *RetractRatingRequest) (*RetractRatingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetractRating not implemented")
+}
+func (UnimplementedLaptopServiceServer) GetRating(context.Context, *GetRatingRequest) (*GetRatingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRating not implemented")
+}
+func (UnimplementedLaptopServiceServer) GetRatings(context.Context, *GetRatingsRequest) (*GetRatingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRatings not implemented")
+}
+func (UnimplementedLaptopServiceServer) TopRatedLaptops(context.Context, *TopRatedLaptopsRequest) (*TopRatedLaptopsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TopRatedLaptops not implemented")
+}
+func (UnimplementedLaptopServiceServer) CreateReview(context.Context, *CreateReviewRequest) (*CreateReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateReview not implemented")
+}
+func (UnimplementedLaptopServiceServer) EditReview(context.Context, *EditReviewRequest) (*EditReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EditReview not implemented")
+}
+func (UnimplementedLaptopServiceServer) DeleteReview(context.Context, *DeleteReviewRequest) (*DeleteReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteReview not implemented")
+}
+func (UnimplementedLaptopServiceServer) ListReviews(context.Context, *ListReviewsRequest) (*ListReviewsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReviews not implemented")
+}
+func (UnimplementedLaptopServiceServer) HideReview(context.Context, *HideReviewRequest) (*HideReviewResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HideReview not implemented")
+}
 func (UnimplementedLaptopServiceServer) mustEmbedUnimplementedLaptopServiceServer() {}
 
 // UnsafeLaptopServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -207,6 +417,78 @@ func _LaptopService_CreateLaptop_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LaptopService_GetLaptop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLaptopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetLaptop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetLaptop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetLaptop(ctx, req.(*GetLaptopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_UpdateLaptop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateLaptopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).UpdateLaptop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_UpdateLaptop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).UpdateLaptop(ctx, req.(*UpdateLaptopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_DeleteLaptop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteLaptopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).DeleteLaptop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_DeleteLaptop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).DeleteLaptop(ctx, req.(*DeleteLaptopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_ListLaptops_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLaptopsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ListLaptops(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ListLaptops_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ListLaptops(ctx, req.(*ListLaptopsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LaptopService_SearchLaptop_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(SearchLaptopRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -228,6 +510,24 @@ func (x *laptopServiceSearchLaptopServer) Send(m *SearchLaptopResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _LaptopService_SearchFacets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchFacetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).SearchFacets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_SearchFacets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).SearchFacets(ctx, req.(*SearchFacetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _LaptopService_UploadImage_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(LaptopServiceServer).UploadImage(&laptopServiceUploadImageServer{stream})
 }
@@ -280,6 +580,168 @@ func (x *laptopServiceRateLaptopServer) Recv() (*RateLaptopRequest, error) {
 	return m, nil
 }
 
+func _LaptopService_RetractRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetractRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).RetractRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_RetractRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).RetractRating(ctx, req.(*RetractRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_GetRating_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRatingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetRating(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetRating_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetRating(ctx, req.(*GetRatingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_GetRatings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRatingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).GetRatings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_GetRatings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).GetRatings(ctx, req.(*GetRatingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_TopRatedLaptops_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TopRatedLaptopsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).TopRatedLaptops(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_TopRatedLaptops_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).TopRatedLaptops(ctx, req.(*TopRatedLaptopsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_CreateReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).CreateReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_CreateReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).CreateReview(ctx, req.(*CreateReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_EditReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).EditReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_EditReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).EditReview(ctx, req.(*EditReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_DeleteReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).DeleteReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_DeleteReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).DeleteReview(ctx, req.(*DeleteReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_ListReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).ListReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_ListReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).ListReviews(ctx, req.(*ListReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LaptopService_HideReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HideReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaptopServiceServer).HideReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LaptopService_HideReview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaptopServiceServer).HideReview(ctx, req.(*HideReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LaptopService_ServiceDesc is the grpc.ServiceDesc for LaptopService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -291,6 +753,62 @@ var LaptopService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateLaptop",
 			Handler:    _LaptopService_CreateLaptop_Handler,
 		},
+		{
+			MethodName: "GetLaptop",
+			Handler:    _LaptopService_GetLaptop_Handler,
+		},
+		{
+			MethodName: "UpdateLaptop",
+			Handler:    _LaptopService_UpdateLaptop_Handler,
+		},
+		{
+			MethodName: "DeleteLaptop",
+			Handler:    _LaptopService_DeleteLaptop_Handler,
+		},
+		{
+			MethodName: "ListLaptops",
+			Handler:    _LaptopService_ListLaptops_Handler,
+		},
+		{
+			MethodName: "SearchFacets",
+			Handler:    _LaptopService_SearchFacets_Handler,
+		},
+		{
+			MethodName: "RetractRating",
+			Handler:    _LaptopService_RetractRating_Handler,
+		},
+		{
+			MethodName: "GetRating",
+			Handler:    _LaptopService_GetRating_Handler,
+		},
+		{
+			MethodName: "GetRatings",
+			Handler:    _LaptopService_GetRatings_Handler,
+		},
+		{
+			MethodName: "TopRatedLaptops",
+			Handler:    _LaptopService_TopRatedLaptops_Handler,
+		},
+		{
+			MethodName: "CreateReview",
+			Handler:    _LaptopService_CreateReview_Handler,
+		},
+		{
+			MethodName: "EditReview",
+			Handler:    _LaptopService_EditReview_Handler,
+		},
+		{
+			MethodName: "DeleteReview",
+			Handler:    _LaptopService_DeleteReview_Handler,
+		},
+		{
+			MethodName: "ListReviews",
+			Handler:    _LaptopService_ListReviews_Handler,
+		},
+		{
+			MethodName: "HideReview",
+			Handler:    _LaptopService_HideReview_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{